@@ -0,0 +1,79 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lexer
+
+import (
+	stdcontext "context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDoContextCanceledBeforeStart(t *testing.T) {
+	l, _ := New(strings.NewReader("foo bar baz"))
+	l.AppendTokenizer(wordTokenizer)
+
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	cancel()
+
+	tokens, err := l.DoContext(ctx, "main.asm")
+	if !errors.Is(err, stdcontext.Canceled) {
+		t.Fatalf("DoContext() error = %v, want context.Canceled", err)
+	}
+	if tokens != nil {
+		t.Errorf("DoContext() tokens = %v, want nil", tokens)
+	}
+}
+
+func TestDoContextRunsToCompletion(t *testing.T) {
+	l, _ := New(strings.NewReader("foo bar baz"))
+	l.AppendTokenizer(wordTokenizer)
+
+	tokens, err := l.DoContext(stdcontext.Background(), "main.asm")
+	if err != nil {
+		t.Fatalf("DoContext() error = %v", err)
+	}
+	if len(tokens) != 3 {
+		t.Fatalf("DoContext() tokens length = %v, want 3", len(tokens))
+	}
+}
+
+func TestStatsAfterDo(t *testing.T) {
+	l, _ := New(strings.NewReader("foo bar baz"))
+	l.AppendTokenizer(wordTokenizer)
+
+	if _, err := l.Do("main.asm"); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	stats := l.Stats()
+	if stats.TokensEmitted != 3 {
+		t.Errorf("Stats().TokensEmitted = %v, want 3", stats.TokensEmitted)
+	}
+	if stats.BytesConsumed != len("foo bar baz") {
+		t.Errorf("Stats().BytesConsumed = %v, want %v", stats.BytesConsumed, len("foo bar baz"))
+	}
+	if stats.TokensPerSecEMA <= 0 {
+		t.Errorf("Stats().TokensPerSecEMA = %v, want > 0", stats.TokensPerSecEMA)
+	}
+}
+
+func TestStatsZeroBeforeDo(t *testing.T) {
+	l, _ := New(strings.NewReader("foo"))
+	stats := l.Stats()
+	if stats != (Stats{}) {
+		t.Errorf("Stats() before any Do() = %+v, want the zero Stats", stats)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lexer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dywoq/miniasm/token"
+)
+
+// ErrorKind classifies the severity of an Error.
+type ErrorKind int
+
+const (
+	KindError ErrorKind = iota
+	KindWarning
+	KindInfo
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindWarning:
+		return "warning"
+	case KindInfo:
+		return "info"
+	default:
+		return "error"
+	}
+}
+
+// Error is a single positioned lexer diagnostic. Callers that need more
+// than the formatted message - an LSP integration, say - can type-assert
+// a returned error to *Error to inspect its fields directly instead of
+// reparsing Error's string.
+type Error struct {
+	Kind     ErrorKind
+	Filename string
+	Position *token.Position
+	Length   int
+	Message  string
+
+	// line is the offending source line's text, captured at the time
+	// the Error was built so Error() can still render it even after a
+	// streaming Lexer has evicted it from its sliding window.
+	line string
+}
+
+// Error renders e as a "file:line:col: message" header followed by the
+// offending source line and a "~~~~^" underline spanning Length columns
+// and ending at Position.Column, e.g.:
+//
+//	foo.asm:3:8: unknown character
+//	    mov %rax, %rbx
+//	        ~~~^
+func (e *Error) Error() string {
+	header := fmt.Sprintf("%v:%v:%v: %v", e.Filename, e.Position.Line, e.Position.Column, e.Message)
+	if e.line == "" {
+		return header
+	}
+	return fmt.Sprintf("%v\n    %v\n    %v", header, e.line, caret(e.Position.Column, e.Length))
+}
+
+// caret builds a "~~~~^" underline of width length ending at col
+// (1-based), or a bare "^" when length is 0 or 1.
+func caret(col, length int) string {
+	if length < 1 {
+		length = 1
+	}
+	indent := col - length
+	if indent < 0 {
+		indent = 0
+	}
+	return strings.Repeat(" ", indent) + strings.Repeat("~", length-1) + "^"
+}
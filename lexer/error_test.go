@@ -0,0 +1,122 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dywoq/miniasm/lexer/tokenizer"
+	"github.com/dywoq/miniasm/token"
+)
+
+func TestErrorKindString(t *testing.T) {
+	tests := []struct {
+		kind ErrorKind
+		want string
+	}{
+		{KindError, "error"},
+		{KindWarning, "warning"},
+		{KindInfo, "info"},
+	}
+	for _, tt := range tests {
+		if got := tt.kind.String(); got != tt.want {
+			t.Errorf("%v.String() = %v, want %v", int(tt.kind), got, tt.want)
+		}
+	}
+}
+
+func TestErrorErrorNoLine(t *testing.T) {
+	e := &Error{
+		Kind:     KindError,
+		Filename: "test.asm",
+		Position: &token.Position{Line: 1, Column: 1},
+		Message:  "unknown character",
+	}
+	want := "test.asm:1:1: unknown character"
+	if got := e.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestErrorErrorWithLine(t *testing.T) {
+	e := &Error{
+		Kind:     KindError,
+		Filename: "foo.asm",
+		Position: &token.Position{Line: 3, Column: 9},
+		Length:   4,
+		Message:  "unknown character",
+		line:     "mov %rax, %rbx",
+	}
+	got := e.Error()
+	wantHeader := "foo.asm:3:9: unknown character"
+	if !strings.HasPrefix(got, wantHeader) {
+		t.Fatalf("Error() = %q, want prefix %q", got, wantHeader)
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Error() has %v lines, want 3:\n%v", len(lines), got)
+	}
+	if lines[1] != "    mov %rax, %rbx" {
+		t.Errorf("Error() source line = %q, want %q", lines[1], "    mov %rax, %rbx")
+	}
+	if lines[2] != "         ~~~^" {
+		t.Errorf("Error() underline = %q, want %q", lines[2], "         ~~~^")
+	}
+}
+
+func TestDoUnknownCharacterIsError(t *testing.T) {
+	l, _ := New(strings.NewReader("@"))
+	d := &tokenizer.Default{}
+	d.Append(l)
+
+	_, err := l.Do("test.asm")
+	if err == nil {
+		t.Fatal("Do() should return error for unknown character")
+	}
+
+	lexErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Do() error type = %T, want *lexer.Error", err)
+	}
+	if lexErr.Kind != KindError {
+		t.Errorf("Do() error Kind = %v, want KindError", lexErr.Kind)
+	}
+	if lexErr.Filename != "test.asm" {
+		t.Errorf("Do() error Filename = %v, want test.asm", lexErr.Filename)
+	}
+}
+
+func TestContextNewErrorAt(t *testing.T) {
+	l, _ := New(strings.NewReader("mov %rax"))
+	c := &context{l}
+
+	pos := &token.Position{Line: 1, Column: 9, Position: 8}
+	err := c.NewErrorAt(pos, 4, "bad register")
+	if err == nil {
+		t.Fatal("NewErrorAt() should return an error")
+	}
+
+	lexErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("NewErrorAt() error type = %T, want *lexer.Error", err)
+	}
+	if lexErr.Length != 4 {
+		t.Errorf("NewErrorAt() error Length = %v, want 4", lexErr.Length)
+	}
+	if lexErr.Position != pos {
+		t.Errorf("NewErrorAt() error Position = %v, want %v", lexErr.Position, pos)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lexer
+
+import (
+	"io"
+	"sync"
+)
+
+// Pos is an opaque offset into a FileSet's global address space,
+// analogous to go/token.Pos. The zero Pos is NoPos, meaning "no position".
+type Pos int
+
+// NoPos means "no position"; it is never a valid Pos returned by a file
+// added to a FileSet, since NewFileSet starts the first file at base 1,
+// leaving offset 0 as a gap.
+const NoPos Pos = 0
+
+// File tracks a single source added to a FileSet: its name, the bytes it
+// was read from, and its base offset in the set's global address space.
+type File struct {
+	name  string
+	base  int
+	src   []byte
+	lines []int // file-relative offsets of each line's first byte; lines[0] == 0
+}
+
+// Name returns the filename the File was added under.
+func (f *File) Name() string { return f.name }
+
+// Base returns the File's base offset in its FileSet's address space.
+func (f *File) Base() int { return f.base }
+
+// Size returns the file's size in bytes.
+func (f *File) Size() int { return len(f.src) }
+
+// Pos returns the global Pos of the file-relative byte offset.
+func (f *File) Pos(offset int) Pos { return Pos(f.base + offset) }
+
+// position resolves the file-relative byte offset to a Position
+// pointing back at f.
+func (f *File) position(offset int) Position {
+	line, col := 1, offset+1
+	for i, start := range f.lines {
+		if start > offset {
+			break
+		}
+		line = i + 1
+		col = offset - start + 1
+	}
+	return Position{Filename: f.name, Line: line, Column: col, Offset: offset}
+}
+
+// Position is the result of resolving a Pos through a FileSet back to
+// the file, line, column, and byte offset it came from, analogous to
+// go/token.Position.
+type Position struct {
+	Filename string
+	Line     int
+	Column   int
+	Offset   int
+}
+
+// FileSet is a set of source files sharing one monotonically increasing
+// base offset, analogous to go/token.FileSet. A single global Pos
+// round-trips to the (file, line, column) it came from via
+// FileSet.Position, so tokens read from different files - by
+// Lexer.DoFileSet, for include/import-like directives - can be merged
+// into one token stream without losing provenance.
+type FileSet struct {
+	mu    sync.Mutex
+	base  int
+	files []*File
+}
+
+// NewFileSet creates an empty FileSet.
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1}
+}
+
+// Add reads r fully and adds it to the set under filename, returning the
+// *File that tracks its base offset and line table.
+//
+// Returns an error if reading r fails.
+func (s *FileSet) Add(filename string, r io.Reader) (*File, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := &File{name: filename, base: s.base, src: src, lines: []int{0}}
+	for i, b := range src {
+		if b == '\n' {
+			f.lines = append(f.lines, i+1)
+		}
+	}
+	s.files = append(s.files, f)
+	s.base += len(src) + 1 // +1 so Pos(file.base+size) stays inside this file, not the next
+	return f, nil
+}
+
+// File returns the *File that p belongs to, or nil if p doesn't belong
+// to any file added to the set.
+func (s *FileSet) File(p Pos) *File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range s.files {
+		if int(p) >= f.base && int(p) <= f.base+f.Size() {
+			return f
+		}
+	}
+	return nil
+}
+
+// Position resolves p to a file/line/column/byte-offset Position by
+// finding the file it belongs to. It returns the zero Position if p
+// doesn't belong to any file in the set.
+func (s *FileSet) Position(p Pos) Position {
+	f := s.File(p)
+	if f == nil {
+		return Position{}
+	}
+	return f.position(int(p) - f.base)
+}
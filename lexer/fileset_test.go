@@ -0,0 +1,125 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dywoq/miniasm/lexer/tokenizer"
+)
+
+func TestFileSetAdd(t *testing.T) {
+	fs := NewFileSet()
+
+	f1, err := fs.Add("a.asm", strings.NewReader("foo"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if f1.Name() != "a.asm" {
+		t.Errorf("f1.Name() = %v, want a.asm", f1.Name())
+	}
+	if f1.Base() != 1 {
+		t.Errorf("f1.Base() = %v, want 1", f1.Base())
+	}
+
+	f2, err := fs.Add("b.asm", strings.NewReader("bar"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if f2.Base() <= f1.Base()+f1.Size() {
+		t.Errorf("f2.Base() = %v, want it past f1's range (base %v, size %v)", f2.Base(), f1.Base(), f1.Size())
+	}
+}
+
+func TestFileSetPosition(t *testing.T) {
+	fs := NewFileSet()
+	f, err := fs.Add("a.asm", strings.NewReader("foo\nbar"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	pos := f.Pos(4) // byte offset 4 is 'b' of "bar", line 2 column 1
+	got := fs.Position(pos)
+	if got.Filename != "a.asm" || got.Line != 2 || got.Column != 1 {
+		t.Errorf("Position() = %+v, want {a.asm 2 1 4}", got)
+	}
+}
+
+func TestFileSetPositionUnknown(t *testing.T) {
+	fs := NewFileSet()
+	if _, err := fs.Add("a.asm", strings.NewReader("foo")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	got := fs.Position(Pos(9999))
+	if got != (Position{}) {
+		t.Errorf("Position() = %+v, want zero value for an unknown Pos", got)
+	}
+}
+
+func TestDoFileSet(t *testing.T) {
+	l, _ := New(strings.NewReader(""))
+	d := &tokenizer.Default{}
+	d.Append(l)
+
+	fs := NewFileSet()
+	fa, err := fs.Add("a.asm", strings.NewReader("foo"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := fs.Add("b.asm", strings.NewReader("bar")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	tokens, err := l.DoFileSet(fs)
+	if err != nil {
+		t.Fatalf("DoFileSet() error = %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("DoFileSet() tokens length = %v, want 2", len(tokens))
+	}
+	if tokens[0].Literal != "foo" || tokens[1].Literal != "bar" {
+		t.Fatalf("DoFileSet() tokens = %+v, want foo, bar", tokens)
+	}
+
+	aPos := fs.Position(Pos(tokens[0].Position.Position))
+	if aPos.Filename != "a.asm" {
+		t.Errorf("first token resolves to %v, want a.asm", aPos.Filename)
+	}
+	bPos := fs.Position(Pos(tokens[1].Position.Position))
+	if bPos.Filename != "b.asm" {
+		t.Errorf("second token resolves to %v, want b.asm", bPos.Filename)
+	}
+
+	if fa.Pos(0) == Pos(tokens[1].Position.Position) {
+		t.Error("tokens from different files should have distinct global Pos values")
+	}
+}
+
+func TestDoFileSetError(t *testing.T) {
+	l, _ := New(strings.NewReader(""))
+	d := &tokenizer.Default{}
+	d.Append(l)
+
+	fs := NewFileSet()
+	if _, err := fs.Add("bad.asm", strings.NewReader("@")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if _, err := l.DoFileSet(fs); err == nil {
+		t.Error("DoFileSet() should return an error when a file fails to tokenize")
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lexer
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/dywoq/miniasm/lexer/tokenizer"
+	"github.com/dywoq/miniasm/token"
+)
+
+// wordTokenizer tokenizes a run of letters as an Identifier - everything
+// include_test.go needs from tokenizer.Default without depending on its
+// exact set of token kinds.
+func wordTokenizer(c tokenizer.Context) (*token.Token, bool, error) {
+	if c.Eof() || !unicode.IsLetter(rune(c.Current())) {
+		return nil, true, nil
+	}
+	start := c.Position().Position
+	for !c.Eof() && unicode.IsLetter(rune(c.Current())) {
+		c.Advance()
+	}
+	str, err := c.Slice(start, c.Position().Position)
+	if err != nil {
+		return nil, false, err
+	}
+	return &token.Token{Literal: str, Kind: token.Identifier, Position: c.Position()}, false, nil
+}
+
+// includeTokenizer recognizes "@name", pushing sources[name] as a new
+// source the moment it's seen - a stand-in for a real %include directive.
+func includeTokenizer(sources map[string]string) tokenizer.Tokenizer {
+	return func(c tokenizer.Context) (*token.Token, bool, error) {
+		if c.Current() != '@' {
+			return nil, true, nil
+		}
+		c.Advance()
+		start := c.Position().Position
+		for !c.Eof() && unicode.IsLetter(rune(c.Current())) {
+			c.Advance()
+		}
+		name, err := c.Slice(start, c.Position().Position)
+		if err != nil {
+			return nil, false, err
+		}
+		content, ok := sources[name]
+		if !ok {
+			return nil, false, c.NewError("unknown include: " + name)
+		}
+		if err := c.PushSource(name, strings.NewReader(content)); err != nil {
+			return nil, false, err
+		}
+		return nil, true, nil
+	}
+}
+
+func TestPushSourceAndPop(t *testing.T) {
+	l, _ := New(strings.NewReader("abc"))
+	c := &context{l}
+
+	c.Advance()
+	c.Advance() // now at 'c', outer position = {Line:1 Column:3 Position:2}
+
+	if err := c.PushSource("inc.asm", strings.NewReader("xyz")); err != nil {
+		t.Fatalf("PushSource() error = %v", err)
+	}
+	if l.filename != "inc.asm" {
+		t.Errorf("filename after PushSource() = %v, want inc.asm", l.filename)
+	}
+	if c.Current() != 'x' {
+		t.Errorf("Current() after PushSource() = %v, want x", string(c.Current()))
+	}
+
+	for !c.Eof() {
+		c.Advance()
+	}
+	l.popSource()
+
+	if l.filename != "" {
+		t.Errorf("filename after popSource() = %v, want empty (outer file has none set)", l.filename)
+	}
+	if c.Current() != 'c' {
+		t.Errorf("Current() after popSource() = %v, want c", string(c.Current()))
+	}
+}
+
+func TestPushSourceCycle(t *testing.T) {
+	l, _ := New(strings.NewReader(""))
+	l.filename = "a.asm"
+	c := &context{l}
+
+	err := c.PushSource("a.asm", strings.NewReader("whatever"))
+	if err == nil {
+		t.Fatal("PushSource() should error on an include cycle")
+	}
+	if _, ok := err.(*Error); !ok {
+		t.Errorf("PushSource() cycle error type = %T, want *lexer.Error", err)
+	}
+}
+
+func TestPushSourceNestedCycle(t *testing.T) {
+	l, _ := New(strings.NewReader(""))
+	l.filename = "a.asm"
+	c := &context{l}
+
+	if err := c.PushSource("b.asm", strings.NewReader("")); err != nil {
+		t.Fatalf("PushSource(b.asm) error = %v", err)
+	}
+	if err := c.PushSource("a.asm", strings.NewReader("")); err == nil {
+		t.Fatal("PushSource() should catch a cycle through a suspended source, not just the active one")
+	}
+}
+
+func TestDoWithIncludedSource(t *testing.T) {
+	l, _ := New(strings.NewReader("@inc foo"))
+	l.AppendTokenizer(includeTokenizer(map[string]string{"inc": "bar"}))
+	l.AppendTokenizer(wordTokenizer)
+
+	tokens, err := l.Do("main.asm")
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("Do() tokens length = %v, want 2: %+v", len(tokens), tokens)
+	}
+	if tokens[0].Literal != "bar" {
+		t.Errorf("Do() token[0] literal = %v, want bar", tokens[0].Literal)
+	}
+	if tokens[0].Position.Filename != "inc" {
+		t.Errorf("Do() token[0] Filename = %v, want inc", tokens[0].Position.Filename)
+	}
+	if tokens[1].Literal != "foo" {
+		t.Errorf("Do() token[1] literal = %v, want foo", tokens[1].Literal)
+	}
+	if tokens[1].Position.Filename != "main.asm" {
+		t.Errorf("Do() token[1] Filename = %v, want main.asm", tokens[1].Position.Filename)
+	}
+}
+
+func TestDoWithIncludeCycle(t *testing.T) {
+	l, _ := New(strings.NewReader("@main"))
+	l.AppendTokenizer(includeTokenizer(map[string]string{"main": "@main"}))
+	l.AppendTokenizer(wordTokenizer)
+
+	_, err := l.Do("main")
+	if err == nil {
+		t.Fatal("Do() should error on a self-including source")
+	}
+}
+
+func TestSetIncludeResolverPanicsWhileOn(t *testing.T) {
+	l, _ := New(strings.NewReader("abc"))
+	l.on.Store(true)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("SetIncludeResolver() should panic while the lexer is on")
+		}
+	}()
+	l.SetIncludeResolver(nil)
+}
+
+// fileResolver is a minimal tokenizer.IncludeResolver backed by an
+// in-memory map, enough to exercise Context.Resolver().
+type fileResolver map[string]string
+
+func (r fileResolver) Resolve(fromFile, path string) (io.ReadCloser, string, error) {
+	content, ok := r[path]
+	if !ok {
+		return nil, "", io.ErrUnexpectedEOF
+	}
+	return io.NopCloser(strings.NewReader(content)), path, nil
+}
+
+func TestContextResolver(t *testing.T) {
+	l, _ := New(strings.NewReader(""))
+	resolver := fileResolver{"inc.asm": "bar"}
+	l.SetIncludeResolver(resolver)
+
+	c := &context{l}
+	got := c.Resolver()
+	if got == nil {
+		t.Fatal("Resolver() = nil, want the registered resolver")
+	}
+	rc, name, err := got.Resolve("main.asm", "inc.asm")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if name != "inc.asm" {
+		t.Errorf("Resolve() name = %v, want inc.asm", name)
+	}
+	content, _ := io.ReadAll(rc)
+	if string(content) != "bar" {
+		t.Errorf("Resolve() content = %v, want bar", string(content))
+	}
+}
@@ -15,23 +15,36 @@
 package lexer
 
 import (
+	"bufio"
+	"bytes"
+	stdcontext "context"
 	"fmt"
 	"io"
 	"log"
 	"sync"
 	"sync/atomic"
+	"time"
 	"unicode"
 
 	"github.com/dywoq/miniasm/lexer/tokenizer"
 	"github.com/dywoq/miniasm/token"
 )
 
+// defaultBufSize is the window size NewStreaming uses when bufSize <= 0.
+const defaultBufSize = 4096
+
 type Lexer struct {
 	// base
 	r     io.Reader
 	bytes []byte
 	on    atomic.Bool
 
+	// streaming: when non-nil, bytes only holds a sliding window of the
+	// input (see ensureWindow/evictWindow), instead of the whole file.
+	streamR     *bufio.Reader
+	bufSize     int
+	windowStart int
+
 	// debug
 	debugW      io.Writer
 	debugOn     atomic.Bool
@@ -40,12 +53,89 @@ type Lexer struct {
 	// tokenizers
 	tokenizers []tokenizer.Tokenizer
 
+	// includes: resolver turns an include directive's path into a
+	// pushed source, and sources is the stack of suspended sources
+	// PushSource/popSource save and restore around it.
+	resolver tokenizer.IncludeResolver
+	sources  []*sourceFrame
+
 	// mutex
 	mu sync.Mutex
 
 	// data
 	filename string
 	position *token.Position
+
+	// emitted collects tokens produced through context.Emit during Run;
+	// Do doesn't use it, since its tokenizers return a token directly.
+	emitted []*token.Token
+
+	// stats is refreshed under mu whenever a DoContext call (Do included,
+	// since it calls DoContext) finishes cleanly or is stopped by its ctx;
+	// a call that fails in a tokenizer, or has no tokenizers, leaves it
+	// unchanged. See Stats.
+	stats Stats
+}
+
+// statsEMAAlpha weights Stats.TokensPerSecEMA towards the most recent
+// DoContext call while still smoothing out one-off spikes, the same
+// tradeoff flowcontrol.Monitor's rate averaging makes.
+const statsEMAAlpha = 0.2
+
+// Stats reports a Lexer's throughput as of its most recently completed
+// Do or DoContext call.
+type Stats struct {
+	// BytesConsumed is how far the top-level source advanced. Bytes
+	// pulled in through Context.PushSource aren't counted separately.
+	BytesConsumed int
+
+	// TokensEmitted is how many tokens the call returned.
+	TokensEmitted int
+
+	// Elapsed is how long the call took.
+	Elapsed time.Duration
+
+	// TokensPerSecEMA is an exponential moving average of tokens/sec
+	// across every DoContext call made on this Lexer so far.
+	TokensPerSecEMA float64
+}
+
+// Stats returns the Lexer's throughput as of its most recently completed
+// Do or DoContext call. The zero Stats if neither has completed yet.
+func (l *Lexer) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// updateStats refreshes l.stats with a just-finished DoContext call's
+// counters. Callers must hold l.mu.
+func (l *Lexer) updateStats(bytesConsumed, tokensEmitted int, elapsed time.Duration) {
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(tokensEmitted) / elapsed.Seconds()
+	}
+	if l.stats.TokensPerSecEMA == 0 {
+		l.stats.TokensPerSecEMA = rate
+	} else {
+		l.stats.TokensPerSecEMA = statsEMAAlpha*rate + (1-statsEMAAlpha)*l.stats.TokensPerSecEMA
+	}
+	l.stats.BytesConsumed = bytesConsumed
+	l.stats.TokensEmitted = tokensEmitted
+	l.stats.Elapsed = elapsed
+}
+
+// sourceFrame snapshots one active source's lexing state so
+// Lexer.pushSource can suspend it in favor of an included file, and
+// popSource can restore it once that file hits EOF.
+type sourceFrame struct {
+	r           io.Reader
+	bytes       []byte
+	streamR     *bufio.Reader
+	bufSize     int
+	windowStart int
+	filename    string
+	position    *token.Position
 }
 
 // New creates a new instance of Lexer with debugging automatically turned off.
@@ -92,6 +182,98 @@ func newBase(r io.Reader) (*Lexer, error) {
 	return l, nil
 }
 
+// NewStreaming creates a Lexer that reads r incrementally through a
+// *bufio.Reader instead of loading it into memory up front: l.bytes only
+// ever retains a sliding window of roughly 2*bufSize bytes, the window
+// needed for the longest realistic lookbehind a tokenizer does with
+// Context.Backward/Slice. This lets miniasm assemble very large sources,
+// or lex directly off a pipe or socket, without holding the whole input
+// in memory.
+//
+// Tokenizers that need unbounded lookbehind (re-slicing all the way back
+// to byte 0 of a large file) are incompatible with streaming mode:
+// Context.Slice returns an error once start falls before the retained
+// window's low-water mark, and Context.Backward simply stops at it.
+//
+// Debugging is automatically turned off.
+func NewStreaming(r io.Reader, bufSize int) (*Lexer, error) {
+	if bufSize <= 0 {
+		bufSize = defaultBufSize
+	}
+	l := &Lexer{}
+	l.r = r
+	l.streamR = bufio.NewReader(r)
+	l.bufSize = bufSize
+	l.bytes = []byte{}
+	l.mu = sync.Mutex{}
+	l.on.Store(false)
+	l.debugOn.Store(false)
+	l.debugW = nil
+	l.position = &token.Position{Line: 1, Column: 1, Position: 0}
+	return l, nil
+}
+
+// isStreaming reports whether l is reading through a sliding window
+// instead of holding the whole input in l.bytes.
+func (l *Lexer) isStreaming() bool {
+	return l.streamR != nil
+}
+
+// ensureWindow makes sure byte offset pos is available in l.bytes,
+// refilling from streamR in bufSize-sized reads until it is (or the
+// reader is exhausted), and reports whether pos ended up available.
+// It's a no-op, always reporting pos < len(l.bytes), when l isn't
+// streaming, since newBase already read the whole input up front.
+func (l *Lexer) ensureWindow(pos int) bool {
+	if !l.isStreaming() {
+		return pos < len(l.bytes)
+	}
+	for pos-l.windowStart >= len(l.bytes) {
+		buf := make([]byte, l.bufSize)
+		n, err := l.streamR.Read(buf)
+		if n > 0 {
+			l.bytes = append(l.bytes, buf[:n]...)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if pos-l.windowStart >= len(l.bytes) {
+		return false
+	}
+	l.evictWindow()
+	return true
+}
+
+// evictWindow drops bytes older than the sliding window's low-water
+// mark (l.position.Position - l.bufSize) once the retained buffer grows
+// past 2*bufSize, keeping streaming mode's memory bounded regardless of
+// how large the input is.
+func (l *Lexer) evictWindow() {
+	if !l.isStreaming() || len(l.bytes) <= 2*l.bufSize {
+		return
+	}
+	lowWater := l.position.Position - l.bufSize
+	if lowWater <= l.windowStart {
+		return
+	}
+	drop := lowWater - l.windowStart
+	if drop > len(l.bytes) {
+		drop = len(l.bytes)
+	}
+	l.bytes = l.bytes[drop:]
+	l.windowStart += drop
+}
+
+// byteAt returns the byte at absolute offset pos, refilling the window
+// if needed, and reports false if pos is past EOF.
+func (l *Lexer) byteAt(pos int) (byte, bool) {
+	if !l.ensureWindow(pos) {
+		return 0, false
+	}
+	return l.bytes[pos-l.windowStart], true
+}
+
 // SetReader sets a new reader, which updates the underlying bytes.
 //
 // Panics if the lexer is currently working.
@@ -148,6 +330,19 @@ func (l *Lexer) AppendTokenizer(t tokenizer.Tokenizer) {
 	l.tokenizers = append(l.tokenizers, t)
 }
 
+// SetIncludeResolver registers resolver, letting a tokenizer turn a
+// %include/%import-style directive into a pushed source via
+// Context.PushSource/Context.Resolver.
+// Panics if the lexer is currently working.
+func (l *Lexer) SetIncludeResolver(resolver tokenizer.IncludeResolver) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.on.Load() {
+		panic("lexer is on, can't set include resolver")
+	}
+	l.resolver = resolver
+}
+
 // implements tokenizer.Context
 type context struct {
 	l *Lexer
@@ -155,7 +350,8 @@ type context struct {
 
 func (c *context) Eof() bool {
 	c.DebugPrintln("Eof(): Checking EOF (end of file)")
-	return c.l.position.Position >= len(c.l.bytes)
+	_, ok := c.l.byteAt(c.l.position.Position)
+	return !ok
 }
 
 func (c *context) Sof() bool {
@@ -164,14 +360,22 @@ func (c *context) Sof() bool {
 }
 
 func (c *context) Current() byte {
-	if c.Eof() {
+	b, ok := c.l.byteAt(c.l.position.Position)
+	if !ok {
 		return 0
 	}
-	b := c.l.bytes[c.l.position.Position]
 	c.DebugPrintf("Current(): Getting current character: %v\n", string(b))
 	return b
 }
 
+func (c *context) Peek() byte {
+	b, ok := c.l.byteAt(c.l.position.Position + 1)
+	if !ok {
+		return 0
+	}
+	return b
+}
+
 func (c *context) Advance() {
 	c.DebugPrintln("Advance(): Advancing...")
 	if c.Eof() {
@@ -190,10 +394,15 @@ func (c *context) Advance() {
 	}
 }
 
+// Backward moves one byte backwards. In streaming mode it stops (without
+// error) once Position reaches the retained window's low-water mark
+// (Lexer.windowStart), since bytes older than that have already been
+// evicted; a tokenizer that genuinely needs to look back further than
+// that isn't compatible with streaming mode.
 func (c *context) Backward() {
 	c.DebugPrintln("Backward(): Advancing backwards...")
-	if c.Sof() {
-		c.DebugPrintln("Backward(): Sof() is true, skipping")
+	if c.Sof() || (c.l.isStreaming() && c.l.position.Position <= c.l.windowStart) {
+		c.DebugPrintln("Backward(): Sof() or streaming low-water mark reached, skipping")
 		return
 	}
 	c.DebugPrintln("Backward(): Position--")
@@ -203,8 +412,8 @@ func (c *context) Backward() {
 		c.DebugPrintln("Backward(): Met newline, decreasing Line and detecting column")
 		c.l.position.Line--
 		col := 1
-		for i := c.l.position.Position - 1; i >= 0; i-- {
-			if c.l.bytes[i] == '\n' {
+		for i := c.l.position.Position - 1; i >= c.l.windowStart; i-- {
+			if c.l.bytes[i-c.l.windowStart] == '\n' {
 				break
 			}
 			col++
@@ -221,6 +430,11 @@ func (c *context) Backward() {
 
 func (c *context) Slice(start, end int) (string, error) {
 	c.DebugPrintln("Slice(): Slicing...")
+
+	if end > start {
+		c.l.ensureWindow(end - 1)
+	}
+
 	switch {
 	case start > end:
 		c.DebugPrintln("Slice(): Failed slicing")
@@ -228,18 +442,28 @@ func (c *context) Slice(start, end int) (string, error) {
 	case start < 0:
 		c.DebugPrintln("Slice(): Failed slicing")
 		return "", c.l.makeError(fmt.Sprintf("Start %v is negative", start))
-	case end > len(c.l.bytes):
+	case c.l.isStreaming() && start < c.l.windowStart:
+		c.DebugPrintln("Slice(): Failed slicing")
+		return "", c.l.makeError(fmt.Sprintf("Start %v is before the retained window's low-water mark %v; a streaming lexer doesn't keep bytes read that far back", start, c.l.windowStart))
+	case end > c.l.windowStart+len(c.l.bytes):
 		c.DebugPrintln("Slice(): Failed slicing")
 		return "", c.l.makeError(fmt.Sprintf("End %v is out of bounds", end))
 	}
 
 	c.DebugPrintf("Slice(): Returning: [%v:%v]\n", start, end)
-	return string(c.l.bytes[start:end]), nil
+	return string(c.l.bytes[start-c.l.windowStart : end-c.l.windowStart]), nil
 }
 
+// Position returns a copy of the lexer's current position. It's a copy,
+// rather than l.position itself, because every Tokenizer stamps a
+// token's Position by calling this once and holding onto the result;
+// returning the live pointer would leave every token sharing one
+// instance that later Advance calls - and PushSource/popSource swapping
+// sources out from under it - keep mutating after the fact.
 func (c *context) Position() *token.Position {
 	c.DebugPrintf("Position(): Returning current position")
-	return c.l.position
+	pos := *c.l.position
+	return &pos
 }
 
 func (c *context) DebugPrintf(format string, a ...any) {
@@ -264,6 +488,65 @@ func (c *context) NewError(str string) error {
 	return c.l.makeError(str)
 }
 
+func (c *context) NewErrorAt(pos *token.Position, length int, str string) error {
+	return c.l.makeErrorAt(pos, length, str)
+}
+
+func (c *context) Emit(tok *token.Token) {
+	c.DebugPrintf("Emit(): Emitting token: %v\n", tok.Literal)
+	c.l.emitted = append(c.l.emitted, tok)
+}
+
+func (c *context) PushSource(name string, r io.Reader) error {
+	c.DebugPrintf("PushSource(): Pushing source: %v\n", name)
+	return c.l.pushSource(name, r)
+}
+
+func (c *context) Resolver() tokenizer.IncludeResolver {
+	return c.l.resolver
+}
+
+// StateFn represents a single lexing state in a state-machine-driven
+// alternative to Do's linear tokenizer list: it runs until it can decide
+// what should lex next, returning that as the next StateFn, or nil once
+// there's nothing left to lex. Inspired by Rob Pike's "Lexical Scanning
+// in Go" talk.
+type StateFn func(tokenizer.Context) (StateFn, error)
+
+// Run drives the lexer with the state-function pattern, starting at start
+// and repeatedly invoking the StateFn it returns until one returns nil or
+// an error. Unlike Do, where each tokenizer returns exactly one token,
+// states emit tokens explicitly through Context.Emit, so a single state
+// invocation can produce zero, one, or several tokens before handing off
+// to the next state - useful for prefix-distinguished tokens like
+// assembly's '.' directives, '%' registers, and ';' comments, where the
+// next state is already known instead of needing to be guessed by
+// trial-and-error.
+func (l *Lexer) Run(start StateFn) ([]*token.Token, error) {
+	c := &context{l}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c.DebugPrintln("Starting lexer (Run)...")
+	l.on.Store(true)
+	defer func() {
+		c.DebugPrintln("Lexer ended")
+		l.on.Store(false)
+	}()
+
+	l.emitted = nil
+	for state := start; state != nil; {
+		next, err := state(c)
+		if err != nil {
+			c.DebugPrintln("Encountered an error when running state")
+			return nil, err
+		}
+		state = next
+	}
+	return l.emitted, nil
+}
+
 // Do starts lexer and runs tokenizers, printing debug messages
 // if debug mode is on.
 //
@@ -271,13 +554,38 @@ func (c *context) NewError(str string) error {
 //
 // Returns an error if tokenizer failed to transform
 // input into a token.
+//
+// Do is the single-file case of DoFileSet, but isn't implemented in
+// terms of it: DoFileSet reads each of its files fully into memory
+// (see FileSet.Add), which would silently defeat a streaming Lexer's
+// whole point of not holding the complete input at once. Do keeps
+// working directly against l, streaming window and all; reach for
+// DoFileSet once a source spans more than one file.
+//
+// Do is DoContext with context.Background, so it can't be canceled; use
+// DoContext directly to bound how long lexing a source is allowed to run.
 func (l *Lexer) Do(filename string) ([]*token.Token, error) {
+	return l.DoContext(stdcontext.Background(), filename)
+}
+
+// DoContext works like Do, but also checks ctx at every whitespace-skip
+// boundary in the main loop, returning ctx.Err() as soon as it's been
+// canceled or its deadline has passed instead of running a potentially
+// unbounded input to completion. This makes lexing safe to embed in a
+// long-running service (a build daemon, an LSP server) where a runaway
+// or malicious input must not hang the caller.
+//
+// DoContext also refreshes Lexer.Stats() before returning, whether it
+// finishes cleanly or is interrupted by ctx, so callers can observe
+// throughput without instrumenting the call site themselves.
+func (l *Lexer) DoContext(ctx stdcontext.Context, filename string) ([]*token.Token, error) {
 	c := &context{l}
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	l.filename = filename
+	l.position.Filename = filename
 
 	c.DebugPrintln("Starting lexer...")
 	l.on.Store(true)
@@ -291,11 +599,31 @@ func (l *Lexer) Do(filename string) ([]*token.Token, error) {
 		return nil, nil
 	}
 
+	started := time.Now()
+	startPos := l.position.Position
 	tokens := []*token.Token{}
-	for !c.Eof() {
+	for {
+		// A tokenizer may have pushed one or more included sources via
+		// Context.PushSource; once the innermost one hits EOF, pop back
+		// out to whichever source is still unfinished before deciding
+		// whether lexing as a whole is done.
+		for c.Eof() && len(l.sources) > 0 {
+			l.popSource()
+		}
+		if c.Eof() {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			l.updateStats(l.position.Position-startPos, len(tokens), time.Since(started))
+			return nil, err
+		}
 		// We skip whitespace before and after tokenizing to avoid "Unknown character" error,
 		// whose the source is double empty lines.
 		l.skipWhitespace(c)
+		if err := ctx.Err(); err != nil {
+			l.updateStats(l.position.Position-startPos, len(tokens), time.Since(started))
+			return nil, err
+		}
 		tok, err := l.tokenize(c)
 		if err != nil {
 			return nil, err
@@ -303,11 +631,167 @@ func (l *Lexer) Do(filename string) ([]*token.Token, error) {
 		tokens = append(tokens, tok)
 		l.skipWhitespace(c)
 	}
+	l.updateStats(l.position.Position-startPos, len(tokens), time.Since(started))
 	return tokens, nil
 }
 
-func (l *Lexer) makeError(err string) error {
-	return fmt.Errorf("%v (at %v:%v:%v)", err, l.filename, l.position.Line, l.position.Column)
+// DoFileSet tokenizes every file in fs in order, each through a fresh
+// Lexer that inherits l's tokenizers and debug settings, and returns
+// their tokens concatenated into one stream. Each returned token's
+// Position.Position is rewritten from its per-file byte offset to its
+// global offset in fs (via File.Pos), so fs.Position can resolve any
+// token in the combined stream back to the file, line, and column it
+// came from - the basis for cross-file include/import directives and
+// error reporting that spans several files.
+//
+// Returns an error, without tokenizing any further files, as soon as
+// one file fails to tokenize.
+func (l *Lexer) DoFileSet(fs *FileSet) ([]*token.Token, error) {
+	var all []*token.Token
+	for _, f := range fs.files {
+		sub, err := New(bytes.NewReader(f.src))
+		if err != nil {
+			return nil, err
+		}
+		sub.tokenizers = l.tokenizers
+		if l.DebugOn() {
+			sub.debugOn.Store(true)
+			sub.debugW = l.debugW
+			sub.debugLogger = l.debugLogger
+		}
+
+		tokens, err := sub.Do(f.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, tok := range tokens {
+			// Copy rather than mutate in place: a tokenizer may stamp
+			// several tokens with the same *token.Position, and
+			// rewriting it directly would leave each of them pointing
+			// at whichever token's global offset was written last.
+			pos := *tok.Position
+			pos.Position = int(f.Pos(tok.Position.Position))
+			tok.Position = &pos
+		}
+		all = append(all, tokens...)
+	}
+	return all, nil
+}
+
+// pushSource suspends l's active source onto l.sources and switches it
+// to reading r under name, starting from line 1, column 1. Returns a
+// descriptive *Error, without pushing anything, if name is already
+// active - either the current source or one still suspended further
+// down the stack - since lexing it again would recurse forever.
+func (l *Lexer) pushSource(name string, r io.Reader) error {
+	for _, active := range l.activeNames() {
+		if active == name {
+			return l.makeError(fmt.Sprintf("include cycle detected: %v is already being lexed", name))
+		}
+	}
+
+	src, err := io.ReadAll(r)
+	if closer, ok := r.(io.Closer); ok {
+		closer.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	l.sources = append(l.sources, &sourceFrame{
+		r:           l.r,
+		bytes:       l.bytes,
+		streamR:     l.streamR,
+		bufSize:     l.bufSize,
+		windowStart: l.windowStart,
+		filename:    l.filename,
+		position:    l.position,
+	})
+
+	l.r = r
+	l.bytes = src
+	l.streamR = nil
+	l.windowStart = 0
+	l.filename = name
+	l.position = &token.Position{Line: 1, Column: 1, Position: 0, Filename: name}
+	return nil
+}
+
+// popSource restores the most recently pushed sourceFrame, resuming
+// that source exactly where PushSource suspended it. Does nothing if
+// l.sources is empty.
+func (l *Lexer) popSource() {
+	n := len(l.sources)
+	if n == 0 {
+		return
+	}
+	f := l.sources[n-1]
+	l.sources = l.sources[:n-1]
+
+	l.r = f.r
+	l.bytes = f.bytes
+	l.streamR = f.streamR
+	l.bufSize = f.bufSize
+	l.windowStart = f.windowStart
+	l.filename = f.filename
+	l.position = f.position
+}
+
+// activeNames returns the filenames on l's include stack plus the
+// currently active one, the set pushSource checks a new include's name
+// against to catch cycles.
+func (l *Lexer) activeNames() []string {
+	names := make([]string, 0, len(l.sources)+1)
+	for _, f := range l.sources {
+		names = append(names, f.filename)
+	}
+	return append(names, l.filename)
+}
+
+// makeError builds an *Error at l's current position, spanning a single
+// column.
+func (l *Lexer) makeError(str string) error {
+	return l.makeErrorAt(l.position, 1, str)
+}
+
+// makeErrorAt builds an *Error spanning length columns and ending at
+// pos, so a tokenizer can flag the whole bad token it found instead of
+// just the single column makeError reports.
+func (l *Lexer) makeErrorAt(pos *token.Position, length int, str string) error {
+	return &Error{
+		Kind:     KindError,
+		Filename: l.filename,
+		Position: pos,
+		Length:   length,
+		Message:  str,
+		line:     l.lineAt(pos.Position),
+	}
+}
+
+// lineAt returns the text of the source line containing byte offset
+// pos, without its trailing newline. In streaming mode the scan stops
+// at the retained window's low-water mark, same as context.Backward,
+// so a line whose start has already been evicted comes back truncated
+// rather than erroring.
+func (l *Lexer) lineAt(pos int) string {
+	lo := pos
+	for lo > 0 && !(l.isStreaming() && lo <= l.windowStart) {
+		b, ok := l.byteAt(lo - 1)
+		if !ok || b == '\n' {
+			break
+		}
+		lo--
+	}
+
+	var buf []byte
+	for i := lo; ; i++ {
+		b, ok := l.byteAt(i)
+		if !ok || b == '\n' {
+			break
+		}
+		buf = append(buf, b)
+	}
+	return string(buf)
 }
 
 func (l *Lexer) tokenize(c *context) (*token.Token, error) {
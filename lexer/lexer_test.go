@@ -16,8 +16,10 @@ package lexer
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 	"testing"
+	"unicode"
 
 	"github.com/dywoq/miniasm/lexer/tokenizer"
 	"github.com/dywoq/miniasm/token"
@@ -465,6 +467,28 @@ func TestDoCharWithNonLetter(t *testing.T) {
 	}
 }
 
+func TestDoShlexEmptyLiteral(t *testing.T) {
+	input := `""`
+	l, _ := New(strings.NewReader(input))
+	d := &tokenizer.Default{}
+	l.AppendTokenizer(d.Shlex)
+
+	tokens, err := l.Do("test.asm")
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if len(tokens) != 1 {
+		t.Fatalf("Do() tokens length = %v, want 1", len(tokens))
+	}
+	if tokens[0].Literal != "" {
+		t.Errorf("Do() shlex literal = %q, want empty string", tokens[0].Literal)
+	}
+	if tokens[0].Kind != token.Shlex {
+		t.Errorf("Do() shlex kind = %v, want Shlex", tokens[0].Kind)
+	}
+}
+
 func TestDoLineNumberTracking(t *testing.T) {
 	input := "foo\nbar\nbaz"
 	l, _ := New(strings.NewReader(input))
@@ -493,6 +517,141 @@ func TestDoLineNumberTracking(t *testing.T) {
 	}
 }
 
+func TestNewStreaming(t *testing.T) {
+	input := "foo 123"
+	l, err := NewStreaming(strings.NewReader(input), 4)
+	if err != nil {
+		t.Fatalf("NewStreaming() error = %v", err)
+	}
+	if !l.isStreaming() {
+		t.Error("NewStreaming() lexer should be streaming")
+	}
+	if len(l.bytes) != 0 {
+		t.Errorf("NewStreaming() bytes should start empty, got %v bytes", len(l.bytes))
+	}
+}
+
+func TestStreamingDo(t *testing.T) {
+	input := "foo 123 bar"
+	l, err := NewStreaming(strings.NewReader(input), 4)
+	if err != nil {
+		t.Fatalf("NewStreaming() error = %v", err)
+	}
+	d := &tokenizer.Default{}
+	d.Append(l)
+
+	tokens, err := l.Do("test.asm")
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(tokens) != 3 {
+		t.Fatalf("Do() tokens length = %v, want 3", len(tokens))
+	}
+	if tokens[0].Literal != "foo" || tokens[1].Literal != "123" || tokens[2].Literal != "bar" {
+		t.Errorf("Do() tokens = %+v, want foo, 123, bar", tokens)
+	}
+}
+
+func TestStreamingSliceBeforeLowWaterMark(t *testing.T) {
+	input := strings.Repeat("a", 32)
+	l, err := NewStreaming(strings.NewReader(input), 4)
+	if err != nil {
+		t.Fatalf("NewStreaming() error = %v", err)
+	}
+	c := &context{l}
+
+	for range 20 {
+		c.Advance()
+	}
+
+	if _, err := c.Slice(0, 1); err == nil {
+		t.Error("Slice() should error when start is before the retained window")
+	}
+}
+
+// lexIdentifierState emits every run of letters as an identifier token,
+// transitioning to lexSeparatorState on anything else.
+func lexIdentifierState(c tokenizer.Context) (StateFn, error) {
+	if c.Eof() {
+		return nil, nil
+	}
+	if !unicode.IsLetter(rune(c.Current())) {
+		return lexSeparatorState, nil
+	}
+	start := c.Position().Position
+	for !c.Eof() && unicode.IsLetter(rune(c.Current())) {
+		c.Advance()
+	}
+	lit, err := c.Slice(start, c.Position().Position)
+	if err != nil {
+		return nil, err
+	}
+	c.Emit(&token.Token{Literal: lit, Kind: token.Identifier, Position: c.Position()})
+	return lexSeparatorState, nil
+}
+
+// lexSeparatorState emits a single non-letter byte as a separator token,
+// then hands back to lexIdentifierState.
+func lexSeparatorState(c tokenizer.Context) (StateFn, error) {
+	if c.Eof() {
+		return nil, nil
+	}
+	if unicode.IsLetter(rune(c.Current())) {
+		return lexIdentifierState, nil
+	}
+	lit := string(c.Current())
+	c.Advance()
+	c.Emit(&token.Token{Literal: lit, Kind: token.Separator, Position: c.Position()})
+	return lexIdentifierState, nil
+}
+
+func TestRun(t *testing.T) {
+	l, _ := New(strings.NewReader("foo;bar"))
+
+	tokens, err := l.Run(lexIdentifierState)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(tokens) != 3 {
+		t.Fatalf("Run() tokens length = %v, want 3", len(tokens))
+	}
+	if tokens[0].Literal != "foo" || tokens[0].Kind != token.Identifier {
+		t.Errorf("Run() first token = %+v, want identifier foo", tokens[0])
+	}
+	if tokens[1].Literal != ";" || tokens[1].Kind != token.Separator {
+		t.Errorf("Run() second token = %+v, want separator ;", tokens[1])
+	}
+	if tokens[2].Literal != "bar" || tokens[2].Kind != token.Identifier {
+		t.Errorf("Run() third token = %+v, want identifier bar", tokens[2])
+	}
+}
+
+func TestRunEmptyInput(t *testing.T) {
+	l, _ := New(strings.NewReader(""))
+
+	tokens, err := l.Run(lexIdentifierState)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(tokens) != 0 {
+		t.Errorf("Run() tokens length = %v, want 0", len(tokens))
+	}
+}
+
+func TestRunStateError(t *testing.T) {
+	l, _ := New(strings.NewReader("foo"))
+
+	errState := func(c tokenizer.Context) (StateFn, error) {
+		return nil, fmt.Errorf("boom")
+	}
+
+	_, err := l.Run(errState)
+	if err == nil {
+		t.Error("Run() should return the error a state produces")
+	}
+}
+
 func TestDoDebugOutput(t *testing.T) {
 	input := "foo"
 	buf := &bytes.Buffer{}
@@ -27,6 +27,8 @@ type Default struct {
 
 // Append appends all tokenizers into a.
 func (d *Default) Append(a Appender) {
+	a.AppendTokenizer(d.Comment)
+	a.AppendTokenizer(d.Directive)
 	a.AppendTokenizer(d.Identifier)
 	a.AppendTokenizer(d.Number)
 	a.AppendTokenizer(d.Separator)
@@ -35,6 +37,78 @@ func (d *Default) Append(a Appender) {
 	a.AppendTokenizer(d.Type)
 }
 
+// Comment recognizes a '#' or '//' line comment and consumes everything up
+// to (but not including) the next newline or EOF. The returned token's
+// literal is the comment text without the leading marker.
+func (d *Default) Comment(c Context) (*token.Token, bool, error) {
+	c.DebugPrintln("Comment(): Met a possible comment")
+
+	cur := c.Current()
+	markerLen := 1
+	switch {
+	case cur == '#':
+		markerLen = 1
+	case cur == '/' && c.Peek() == '/':
+		markerLen = 2
+	default:
+		c.DebugPrintln("Comment(): No match")
+		return nil, true, nil
+	}
+
+	for range markerLen {
+		c.Advance()
+	}
+
+	start := c.Position().Position
+	for {
+		cur := c.Current()
+		if cur == 0 || cur == '\n' {
+			break
+		}
+		c.Advance()
+	}
+	end := c.Position().Position
+
+	str, err := c.Slice(start, end)
+	if err != nil {
+		return nil, false, err
+	}
+	c.DebugPrintf("Comment(): %v is a comment\n", str)
+	return token.New(str, token.Comment, c.Position()), false, nil
+}
+
+// Directive recognizes a preprocessor directive keyword such as %define,
+// %include, %ifdef, %ifndef, %endif, %macro or %endmacro: a leading '%'
+// followed by one or more letters, captured as a single token.Directive
+// token so a preprocessor can dispatch on its literal without having to
+// recompose it from separate tokens.
+func (d *Default) Directive(c Context) (*token.Token, bool, error) {
+	c.DebugPrintln("Directive(): Met a possible directive")
+
+	if c.Current() != '%' {
+		c.DebugPrintln("Directive(): No match")
+		return nil, true, nil
+	}
+
+	start := c.Position().Position
+	c.Advance()
+	for {
+		cur := c.Current()
+		if cur == 0 || !unicode.IsLetter(rune(cur)) {
+			break
+		}
+		c.Advance()
+	}
+	end := c.Position().Position
+
+	str, err := c.Slice(start, end)
+	if err != nil {
+		return nil, false, err
+	}
+	c.DebugPrintf("Directive(): %v is a directive\n", str)
+	return token.New(str, token.Directive, c.Position()), false, nil
+}
+
 func (d *Default) Identifier(c Context) (*token.Token, bool, error) {
 	c.DebugPrintln("Identifier(): Met a possible identifier")
 
@@ -142,6 +216,44 @@ func (d *Default) String(c Context) (*token.Token, bool, error) {
 	return token.New(str, token.String, c.Position()), false, nil
 }
 
+// Shlex tokenizes a quoted literal the same way String does, but tags
+// the result token.Shlex instead of token.String: the raw content
+// between quotes is returned unsplit, left for a downstream consumer to
+// expand via token.ShlexSplit into properly split identifier/string argument
+// tokens (e.g. for `exec "ls -l /tmp"`). It isn't part of Default.Append;
+// embedders opt into it explicitly where they want that ergonomics,
+// instead of (or alongside) the plain String tokenizer.
+func (d *Default) Shlex(c Context) (*token.Token, bool, error) {
+	c.DebugPrintln("Shlex(): Met a possible shlex literal")
+	cur := c.Current()
+	if cur == 0 || cur != '"' {
+		c.DebugPrintln("Shlex(): No match")
+		return nil, true, nil
+	}
+
+	c.Advance() // consume opening quote
+	start := c.Position().Position
+	for {
+		cur := c.Current()
+		if c.Eof() {
+			c.DebugPrintln("Shlex(): Unexpected EOF")
+			return nil, false, c.NewError("Shlex(): Found EOF when tokenizing shlex literal, expected closing \"")
+		}
+		if cur == '"' {
+			break
+		}
+		c.Advance()
+	}
+	end := c.Position().Position
+	c.Advance() // consume closing quote
+	str, err := c.Slice(start, end)
+	if err != nil {
+		return nil, false, err
+	}
+	c.DebugPrintf("Shlex(): %v is a shlex literal\n", str)
+	return token.New(str, token.Shlex, c.Position()), false, nil
+}
+
 func (d *Default) Char(c Context) (*token.Token, bool, error) {
 	c.DebugPrintln("Char(): Met a possible char")
 	cur := c.Current()
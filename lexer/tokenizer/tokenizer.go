@@ -15,6 +15,8 @@
 package tokenizer
 
 import (
+	"io"
+
 	"github.com/dywoq/miniasm/token"
 )
 
@@ -52,6 +54,10 @@ type Context interface {
 	// Returns 0 if lexer encountered end of file.
 	Current() byte
 
+	// Peek returns the byte right after Current without advancing.
+	// Returns 0 if that position is at or past end of file.
+	Peek() byte
+
 	// Slice slices the input within start and end, returning string.
 	// Returns error if:
 	// 	- start is higher than end;
@@ -62,9 +68,44 @@ type Context interface {
 	// Position returns the current position in the file.
 	Position() *token.Position
 
+	// Emit appends tok directly to the lexer's output. It exists for
+	// Lexer.Run's state-function mode, where a single StateFn invocation
+	// can produce more than one token instead of returning exactly one
+	// like a Tokenizer does.
+	Emit(tok *token.Token)
+
+	// NewError builds a positioned error at the lexer's current
+	// position, spanning a single column.
+	NewError(str string) error
+
+	// NewErrorAt builds a positioned error spanning length columns and
+	// ending at pos, so a tokenizer can flag the whole offending token
+	// instead of just the column NewError reports.
+	NewErrorAt(pos *token.Position, length int, str string) error
+
+	// PushSource suspends the active source and switches the lexer to
+	// reading r under name, so the Do loop keeps tokenizing from it
+	// until EOF, then resumes the suspended source where it left off -
+	// the mechanism an %include/%import-style directive tokenizer uses.
+	// Returns an error if name is already active on the include stack
+	// (a cycle).
+	PushSource(name string, r io.Reader) error
+
+	// Resolver returns the lexer's registered IncludeResolver, or nil if
+	// Lexer.SetIncludeResolver was never called.
+	Resolver() IncludeResolver
+
 	Debugging
 }
 
+// IncludeResolver resolves a %include/%import-style directive's path,
+// written in fromFile, to the reader it names and the name to record it
+// under (e.g. a resolved absolute path). The caller is responsible for
+// closing the returned io.ReadCloser once it's been fully read.
+type IncludeResolver interface {
+	Resolve(fromFile, path string) (io.ReadCloser, string, error)
+}
+
 // Tokenizer represents function that transforms input
 // into a token. Each tokenizer always has only one responsibility
 // of tokenizing something, forming modular design.
@@ -20,9 +20,17 @@ type Node interface {
 	Node()
 }
 
+// Tree is the root of a parsed MiniASM source file.
+type Tree struct {
+	TopLevel []Node          `json:"top_level"`
+	Comments []*CommentGroup `json:"comments,omitempty"`
+}
+
 type TopLevel struct {
-	Identifier string `json:"identifier"`
-	Expression Node   `json:"expression"`
+	Identifier string        `json:"identifier"`
+	Expression Node          `json:"expression"`
+	Doc        *CommentGroup `json:"doc,omitempty"`
+	Comment    *CommentGroup `json:"comment,omitempty"`
 }
 
 type Value struct {
@@ -32,11 +40,59 @@ type Value struct {
 
 type ReferenceToIdentifier struct {
 	Identifier string `json:"identifier"`
+	obj        *Object
+}
+
+// Obj returns the Object this reference resolves to, or nil if the
+// reference hasn't been resolved (e.g. parser/resolver.Resolve was never
+// run, or the name is undeclared).
+func (r *ReferenceToIdentifier) Obj() *Object {
+	return r.obj
+}
+
+// SetObj links the reference to the Object it resolves to. It's meant to
+// be called by a resolver pass, not by code building an AST by hand.
+func (r *ReferenceToIdentifier) SetObj(o *Object) {
+	r.obj = o
+}
+
+// ObjKind classifies what an Object was declared as.
+type ObjKind int
+
+const (
+	ObjTopLevel ObjKind = iota
+	ObjFunctionArg
+	ObjBuiltin
+)
+
+func (k ObjKind) String() string {
+	switch k {
+	case ObjTopLevel:
+		return "top-level"
+	case ObjFunctionArg:
+		return "function argument"
+	case ObjBuiltin:
+		return "builtin"
+	default:
+		return "unknown"
+	}
+}
+
+// Object records the declaration a name resolves to, so a
+// ReferenceToIdentifier can be traced back to where it was declared
+// without the consumer re-implementing name lookup.
+type Object struct {
+	Kind     ObjKind
+	Name     string
+	Decl     Node
+	Position *token.Position
 }
 
 type Function struct {
-	Args []FunctionArgument `json:"args"`
-	Body []Instruction      `json:"body"`
+	Args    []FunctionArgument `json:"args"`
+	Body    []Instruction      `json:"body"`
+	Doc     *CommentGroup      `json:"doc,omitempty"`
+	Comment *CommentGroup      `json:"comment,omitempty"`
 }
 
 type FunctionArgument struct {
@@ -45,13 +101,56 @@ type FunctionArgument struct {
 }
 
 type Instruction struct {
+	Name    string        `json:"name"`
+	Args    []Node        `json:"args"`
+	Doc     *CommentGroup `json:"doc,omitempty"`
+	Comment *CommentGroup `json:"comment,omitempty"`
+}
+
+type Array struct {
+	Elements  []Node `json:"elements"`
+	Fixed     bool   `json:"fixed"`
+	FixedSize int    `json:"fixed_size"`
+}
+
+type SpecialFunction struct {
 	Name string `json:"name"`
 	Args []Node `json:"args"`
 }
 
+// Comment represents a single '#' or '//' line comment as it appeared in
+// the source, without the leading marker.
+type Comment struct {
+	Text     string          `json:"text"`
+	Position *token.Position `json:"position"`
+}
+
+// CommentGroup represents a sequence of comments with no other tokens and
+// no blank lines between them.
+type CommentGroup struct {
+	List []*Comment `json:"list"`
+}
+
+// Text returns the comments of the group joined by newlines.
+func (g *CommentGroup) Text() string {
+	if g == nil {
+		return ""
+	}
+	text := ""
+	for i, c := range g.List {
+		if i > 0 {
+			text += "\n"
+		}
+		text += c.Text
+	}
+	return text
+}
+
 func (TopLevel) Node()              {}
 func (Value) Node()                 {}
 func (Function) Node()              {}
 func (FunctionArgument) Node()      {}
 func (Instruction) Node()           {}
 func (ReferenceToIdentifier) Node() {}
+func (Array) Node()                 {}
+func (SpecialFunction) Node()       {}
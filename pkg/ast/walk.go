@@ -0,0 +1,122 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import "fmt"
+
+// Visitor visits nodes of an AST. Visit is called with the node being
+// walked; if it returns a non-nil Visitor w, Walk visits each of node's
+// children with w, then calls w.Visit(nil) once all children have been
+// visited.
+type Visitor interface {
+	Visit(node Node) Visitor
+}
+
+// Walk traverses an AST in pre-order, children left-to-right, calling
+// v.Visit for node and each of its descendants. It accepts both the
+// pointer and value forms of a node, since mini parsers aren't
+// consistent about which one they hand back.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *TopLevel:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+	case TopLevel:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+
+	case *Function:
+		for _, arg := range n.Args {
+			Walk(v, arg)
+		}
+		for _, instr := range n.Body {
+			Walk(v, instr)
+		}
+	case Function:
+		for _, arg := range n.Args {
+			Walk(v, arg)
+		}
+		for _, instr := range n.Body {
+			Walk(v, instr)
+		}
+
+	case *Instruction:
+		for _, a := range n.Args {
+			Walk(v, a)
+		}
+	case Instruction:
+		for _, a := range n.Args {
+			Walk(v, a)
+		}
+
+	case *Array:
+		for _, e := range n.Elements {
+			Walk(v, e)
+		}
+	case Array:
+		for _, e := range n.Elements {
+			Walk(v, e)
+		}
+
+	case *SpecialFunction:
+		for _, a := range n.Args {
+			Walk(v, a)
+		}
+	case SpecialFunction:
+		for _, a := range n.Args {
+			Walk(v, a)
+		}
+
+	case *ReferenceToIdentifier, ReferenceToIdentifier,
+		*Value, Value,
+		*FunctionArgument, FunctionArgument:
+		// leaves: no children to walk
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", node))
+	}
+
+	v.Visit(nil)
+}
+
+// Walk traverses every top-level declaration of the tree with v.
+func (t *Tree) Walk(v Visitor) {
+	for _, n := range t.TopLevel {
+		Walk(v, n)
+	}
+}
+
+// inspector adapts a function to the Visitor interface for Inspect.
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in pre-order, children left-to-right, calling
+// f for node and each of its descendants. If f returns false, Inspect
+// skips over node's children entirely.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diag holds positioned diagnostics in a diag.List and renders
+// them with the offending source line and a caret underline, modeled on
+// go/scanner.ErrorList and go/token.FileSet. Parser errors convert into
+// it (parser.ErrorList.Diagnostics, parser.DoAll); the lexer and its
+// tokenizers report their own *lexer.Error values and don't produce
+// diag.Diagnostics.
+package diag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dywoq/miniasm/pkg/token"
+)
+
+// File holds one file's original source bytes plus a cumulative
+// line-start offset table, so any token.Position can be rendered back to
+// its offending source line.
+type File struct {
+	Name string
+
+	src   []byte
+	lines []int // byte offsets of each line's first byte; lines[0] == 0
+}
+
+// NewFile builds a File from its full source text, scanning it once for
+// line starts.
+func NewFile(name string, src []byte) *File {
+	f := &File{Name: name, src: src, lines: []int{0}}
+	for i, b := range src {
+		if b == '\n' {
+			f.lines = append(f.lines, i+1)
+		}
+	}
+	return f
+}
+
+// Line returns the raw text of the given 1-based line number, without
+// its trailing newline, or "" if line is out of range.
+func (f *File) Line(line int) string {
+	if line < 1 || line > len(f.lines) {
+		return ""
+	}
+	start := f.lines[line-1]
+	end := len(f.src)
+	if line < len(f.lines) {
+		end = f.lines[line] - 1
+	}
+	if end > start && f.src[end-1] == '\r' {
+		end--
+	}
+	return string(f.src[start:end])
+}
+
+// Severity classifies a Diagnostic.
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic is a single positioned error or warning. Length, when
+// greater than 1, is the number of source columns the offending token
+// spans, so Render underlines it with "~~~~^" instead of a bare "^".
+type Diagnostic struct {
+	File     string          `json:"file"`
+	Position *token.Position `json:"position"`
+	Length   int             `json:"length,omitempty"`
+	Severity Severity        `json:"severity"`
+	Message  string          `json:"message"`
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%v:%v:%v: %v: %v", d.File, d.Position.Line, d.Position.Column, d.Severity, d.Message)
+}
+
+// List collects Diagnostics, such as those converted from a parser.Do
+// pass's ErrorList.
+// It implements error so it can be returned directly, same as
+// pkg/parser.ErrorList.
+type List []*Diagnostic
+
+// Add appends d to the list.
+func (l *List) Add(d *Diagnostic) {
+	*l = append(*l, d)
+}
+
+// Addf builds and appends an Error-severity Diagnostic.
+func (l *List) Addf(file string, pos *token.Position, format string, a ...any) {
+	l.Add(&Diagnostic{File: file, Position: pos, Severity: Error, Message: fmt.Sprintf(format, a...)})
+}
+
+func (l List) Len() int      { return len(l) }
+func (l List) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l List) Less(i, j int) bool {
+	a, b := l[i], l[j]
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.Position.Line != b.Position.Line {
+		return a.Position.Line < b.Position.Line
+	}
+	return a.Position.Column < b.Position.Column
+}
+
+// Sort sorts the list by file, then line, then column.
+func (l List) Sort() {
+	sort.Sort(l)
+}
+
+// Err returns the list as an error, or nil if the list is empty.
+func (l List) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+func (l List) Error() string {
+	switch len(l) {
+	case 0:
+		return "no diagnostics"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%v (and %v more)", l[0].Error(), len(l)-1)
+}
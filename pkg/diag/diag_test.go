@@ -0,0 +1,135 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dywoq/miniasm/pkg/token"
+)
+
+func TestCaret(t *testing.T) {
+	tests := []struct {
+		col, length int
+		want        string
+	}{
+		{1, 0, "^"},
+		{1, 1, "^"},
+		{5, 1, "    ^"},
+		{5, 3, "  ~~^"},
+	}
+	for _, tt := range tests {
+		if got := caret(tt.col, tt.length); got != tt.want {
+			t.Errorf("caret(%v, %v) = %q, want %q", tt.col, tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	files := map[string]*File{
+		"test.asm": NewFile("test.asm", []byte("foo bar\n")),
+	}
+	d := &Diagnostic{
+		File:     "test.asm",
+		Position: &token.Position{Line: 1, Column: 5},
+		Length:   3,
+		Severity: Error,
+		Message:  "undeclared name bar",
+	}
+
+	var buf bytes.Buffer
+	Render(&buf, d, files, false)
+
+	out := buf.String()
+	if !strings.Contains(out, "test.asm:1:5: error: undeclared name bar") {
+		t.Errorf("Render() output = %q, want it to contain the diagnostic header", out)
+	}
+	if !strings.Contains(out, "foo bar") {
+		t.Errorf("Render() output = %q, want it to contain the offending source line", out)
+	}
+	if !strings.Contains(out, "~~^") {
+		t.Errorf("Render() output = %q, want it to contain the caret underline", out)
+	}
+}
+
+func TestRenderUnknownFile(t *testing.T) {
+	d := &Diagnostic{
+		File:     "missing.asm",
+		Position: &token.Position{Line: 1, Column: 1},
+		Severity: Error,
+		Message:  "boom",
+	}
+
+	var buf bytes.Buffer
+	Render(&buf, d, map[string]*File{}, false)
+
+	out := buf.String()
+	if !strings.Contains(out, "missing.asm:1:1: error: boom") {
+		t.Errorf("Render() output = %q, want it to still contain the diagnostic header", out)
+	}
+	if strings.Count(out, "\n") != 1 {
+		t.Errorf("Render() output = %q, want only the header line when the file is unknown", out)
+	}
+}
+
+func TestListWriteJSON(t *testing.T) {
+	l := List{
+		{File: "test.asm", Position: &token.Position{Line: 1, Column: 1}, Severity: Error, Message: "bad"},
+	}
+
+	var buf bytes.Buffer
+	if err := l.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"file":"test.asm"`) {
+		t.Errorf("WriteJSON() output = %v, want it to contain the file field", out)
+	}
+	if !strings.Contains(out, `"message":"bad"`) {
+		t.Errorf("WriteJSON() output = %v, want it to contain the message field", out)
+	}
+}
+
+func TestListErr(t *testing.T) {
+	var empty List
+	if err := empty.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil for an empty list", err)
+	}
+
+	l := List{{File: "test.asm", Position: &token.Position{Line: 1, Column: 1}, Message: "bad"}}
+	if err := l.Err(); err == nil {
+		t.Error("Err() = nil, want the list itself as an error")
+	}
+}
+
+func TestFileLine(t *testing.T) {
+	f := NewFile("test.asm", []byte("foo\nbar\nbaz"))
+
+	if got := f.Line(1); got != "foo" {
+		t.Errorf("Line(1) = %q, want %q", got, "foo")
+	}
+	if got := f.Line(3); got != "baz" {
+		t.Errorf("Line(3) = %q, want %q", got, "baz")
+	}
+	if got := f.Line(0); got != "" {
+		t.Errorf("Line(0) = %q, want empty string", got)
+	}
+	if got := f.Line(4); got != "" {
+		t.Errorf("Line(4) = %q, want empty string", got)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBold   = "\x1b[1m"
+	ansiReset  = "\x1b[0m"
+)
+
+// Render writes a human-readable rendering of d to w: a
+// "file:line:col: severity: message" header, the offending source line
+// (found via files, keyed by Diagnostic.File), and a caret under the
+// column, widened to a "~~~~^" underline when d.Length > 1. If color is
+// true, the header's severity and the underline are wrapped in ANSI
+// color codes.
+func Render(w io.Writer, d *Diagnostic, files map[string]*File, color bool) {
+	sev := d.Severity.String()
+	if color {
+		c := ansiRed
+		if d.Severity == Warning {
+			c = ansiYellow
+		}
+		sev = c + ansiBold + sev + ansiReset
+	}
+	fmt.Fprintf(w, "%v:%v:%v: %v: %v\n", d.File, d.Position.Line, d.Position.Column, sev, d.Message)
+
+	file := files[d.File]
+	if file == nil {
+		return
+	}
+	line := file.Line(d.Position.Line)
+	if line == "" {
+		return
+	}
+	fmt.Fprintf(w, "    %v\n", line)
+
+	underline := caret(d.Position.Column, d.Length)
+	if color {
+		underline = ansiBold + underline + ansiReset
+	}
+	fmt.Fprintf(w, "    %v\n", underline)
+}
+
+// caret builds a "~~~~^" underline of width length ending at col
+// (1-based), or a bare "^" when length is 0 or 1.
+func caret(col, length int) string {
+	if length < 1 {
+		length = 1
+	}
+	indent := col - length
+	if indent < 0 {
+		indent = 0
+	}
+	return strings.Repeat(" ", indent) + strings.Repeat("~", length-1) + "^"
+}
+
+// RenderList renders every Diagnostic in l to w via Render, in order.
+func RenderList(w io.Writer, l List, files map[string]*File, color bool) {
+	for _, d := range l {
+		Render(w, d, files, color)
+	}
+}
+
+// WriteJSON emits l as a JSON array, for editor/LSP integration.
+func (l List) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(l)
+}
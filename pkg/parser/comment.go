@@ -0,0 +1,67 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"github.com/dywoq/miniasm/pkg/ast"
+	"github.com/dywoq/miniasm/pkg/token"
+)
+
+// extractComments splits raw (possibly comment-laden) tokens into the
+// comment-free stream mini parsers see and the comment groups found in
+// between. leadFor maps a kept token's index to the group that ends on the
+// line immediately before it (a "lead" doc comment); lineFor maps a kept
+// token's index to a group made of a single comment on that same line,
+// right after it (a "line" comment). A group that starts on that line
+// and continues onto the following lines is not a line comment.
+func extractComments(raw []*token.Token) (kept []*token.Token, all []*ast.CommentGroup, leadFor, lineFor map[int]*ast.CommentGroup) {
+	leadFor = map[int]*ast.CommentGroup{}
+	lineFor = map[int]*ast.CommentGroup{}
+
+	var pending *ast.CommentGroup
+	attach := func(beforeIdx int) {
+		if pending == nil {
+			return
+		}
+		last := pending.List[len(pending.List)-1]
+		switch {
+		case beforeIdx > 0 && last.Position.Line == kept[beforeIdx-1].Position.Line:
+			lineFor[beforeIdx-1] = pending
+		case beforeIdx < len(kept) && last.Position.Line == kept[beforeIdx].Position.Line-1:
+			leadFor[beforeIdx] = pending
+		}
+		all = append(all, pending)
+		pending = nil
+	}
+
+	for _, tok := range raw {
+		if tok.Kind != token.Comment {
+			attach(len(kept))
+			kept = append(kept, tok)
+			continue
+		}
+
+		comment := &ast.Comment{Text: tok.Literal, Position: tok.Position}
+		if pending != nil && tok.Position.Line == pending.List[len(pending.List)-1].Position.Line+1 {
+			pending.List = append(pending.List, comment)
+			continue
+		}
+		attach(len(kept))
+		pending = &ast.CommentGroup{List: []*ast.Comment{comment}}
+	}
+	attach(len(kept))
+
+	return kept, all, leadFor, lineFor
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"sync"
+
+	"github.com/dywoq/miniasm/pkg/ast"
+	"github.com/dywoq/miniasm/pkg/diag"
+	"github.com/dywoq/miniasm/pkg/token"
+)
+
+// File pairs one file's already-lexed token stream with the filename
+// its Parser.Do call should report errors under.
+//
+// There's no lexer.DoAll alongside this: pkg/lexer doesn't exist in this
+// tree (main.go's import of it is already broken at baseline), so the
+// token streams passed in here have to come from wherever callers
+// currently get them.
+type File struct {
+	Name   string
+	Tokens []*token.Token
+}
+
+// DoAll parses each of files concurrently using a pool of workers
+// workers wide, one Parser per file (built by newParser, so callers can
+// vary Mode/FileSet/Vocabulary/mini parser registrations per file), and
+// returns their *ast.Tree results in the same order as files regardless
+// of which file's Do call finishes first. Errors from every file are
+// aggregated into a single diag.List instead of stopping at the first
+// failing file; a file whose Do call errors still gets its (possibly
+// partial) *ast.Tree recorded at its index.
+func DoAll(files []File, newParser func(f File) *Parser, workers int) ([]*ast.Tree, diag.List) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	trees := make([]*ast.Tree, len(files))
+	var diags diag.List
+	var mu sync.Mutex
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f := files[i]
+				p := newParser(f)
+				tree, err := p.Do(f.Name)
+				trees[i] = tree // each index is only ever written by the one worker that claimed it
+
+				if err == nil {
+					continue
+				}
+				mu.Lock()
+				if list, ok := err.(ErrorList); ok {
+					diags = append(diags, list.Diagnostics()...)
+				} else {
+					diags = append(diags, &diag.Diagnostic{File: f.Name, Position: &token.Position{}, Severity: diag.Error, Message: err.Error()})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	diags.Sort()
+	return trees, diags
+}
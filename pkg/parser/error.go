@@ -0,0 +1,137 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dywoq/miniasm/pkg/diag"
+	"github.com/dywoq/miniasm/pkg/token"
+)
+
+// maxErrors caps the number of errors a single Parser.Do call accumulates
+// before it gives up resynchronizing and aborts the rest of the source.
+const maxErrors = 10
+
+// Error represents a single syntax error reported while parsing.
+// Unlike a plain error, it carries the position and filename it
+// was reported at, so a whole ErrorList can be sorted and rendered later.
+type Error struct {
+	Position *token.Position
+	Filename string
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v:%v:%v: %v", e.Filename, e.Position.Line, e.Position.Column, e.Message)
+}
+
+// Diagnostic converts e into a diag.Diagnostic, so callers that want
+// source-snippet rendering (diag.Render) or JSON output (diag.List.WriteJSON)
+// instead of Error's plain "file:line:col: message" string can get one
+// without Parser having to depend on diag itself.
+func (e *Error) Diagnostic() *diag.Diagnostic {
+	return &diag.Diagnostic{
+		File:     e.Filename,
+		Position: e.Position,
+		Severity: diag.Error,
+		Message:  e.Message,
+	}
+}
+
+// ErrorList is a list of *Error accumulated over the course of a single
+// Parser.Do call. It implements error so it can be returned directly.
+type ErrorList []*Error
+
+func (l *ErrorList) add(filename string, pos *token.Position, message string) {
+	*l = append(*l, &Error{Position: pos, Filename: filename, Message: message})
+}
+
+func (l ErrorList) Len() int      { return len(l) }
+func (l ErrorList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l ErrorList) Less(i, j int) bool {
+	a, b := l[i], l[j]
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	if a.Position.Line != b.Position.Line {
+		return a.Position.Line < b.Position.Line
+	}
+	return a.Position.Column < b.Position.Column
+}
+
+// Sort sorts the list by filename, then line, then column, so errors read
+// top-to-bottom the way they occur in the source instead of in whatever
+// order recovery happened to discover them.
+func (l ErrorList) Sort() {
+	sort.Sort(l)
+}
+
+// RemoveMultiples drops errors that share the same filename and position
+// as a previous one, keeping only the first. Resynchronization sometimes
+// reports the same position twice (e.g. an empty instruction immediately
+// followed by another failure); this keeps diagnostics readable.
+//
+// The list must already be sorted.
+func (l *ErrorList) RemoveMultiples() {
+	if len(*l) == 0 {
+		return
+	}
+	out := (*l)[:1]
+	for _, e := range (*l)[1:] {
+		last := out[len(out)-1]
+		if e.Filename == last.Filename && e.Position.Line == last.Position.Line && e.Position.Column == last.Position.Column {
+			continue
+		}
+		out = append(out, e)
+	}
+	*l = out
+}
+
+// Diagnostics converts every *Error in the list into a diag.Diagnostic,
+// for callers that want diag.Render's source-snippet output or
+// diag.List.WriteJSON instead of ErrorList's plain-text Error().
+func (l ErrorList) Diagnostics() diag.List {
+	out := make(diag.List, len(l))
+	for i, e := range l {
+		out[i] = e.Diagnostic()
+	}
+	return out
+}
+
+// Err returns the list as an error, or nil if the list is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("%v (and %v more errors)", msgs[0], len(l)-1) + "\n" + strings.Join(msgs[1:], "\n")
+}
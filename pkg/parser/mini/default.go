@@ -17,15 +17,44 @@ func (d *Default) Append(a Appender) {
 }
 
 func (d *Default) TopLevel(c Context) (ast.Node, bool, error) {
+	doc := c.LeadComment()
 	identifier, ok := c.ExpectKind(token.Identifier)
 	if !ok {
 		return nil, true, nil
 	}
+
+	if c.TopLevelOnly() {
+		d.skipExpression(c)
+		return &ast.TopLevel{Identifier: identifier.Literal, Doc: doc, Comment: c.LineComment()}, false, nil
+	}
+
 	expr, err := d.Expression(c)
 	if err != nil {
 		return nil, false, err
 	}
-	return &ast.TopLevel{Identifier: identifier.Literal, Expression: expr}, false, nil
+	return &ast.TopLevel{Identifier: identifier.Literal, Expression: expr, Doc: doc, Comment: c.LineComment()}, false, nil
+}
+
+// skipExpression advances past a TopLevel's expression without building an
+// AST node for it. It tracks bracket nesting so it doesn't stop in the
+// middle of a function body or array literal, then stops once nesting
+// returns to zero and the next token looks like the start of another
+// top-level declaration (or EOF).
+func (d *Default) skipExpression(c Context) {
+	depth := 0
+	for !c.IsEnd() {
+		cur := c.Current()
+		switch cur.Literal {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		c.Advance()
+		if depth <= 0 && (c.IsEnd() || c.Current().Kind == token.Identifier) {
+			return
+		}
+	}
 }
 
 func (d *Default) Expression(c Context) (ast.Node, error) {
@@ -79,6 +108,7 @@ func (d *Default) Value(c Context) (ast.Node, error) {
 }
 
 func (d *Default) Function(c Context) (ast.Node, error) {
+	doc := c.LeadComment()
 	args, err := d.FunctionArgs(c)
 	if err != nil {
 		return nil, err
@@ -88,8 +118,10 @@ func (d *Default) Function(c Context) (ast.Node, error) {
 		return nil, err
 	}
 	return &ast.Function{
-		Args: args,
-		Body: body,
+		Args:    args,
+		Body:    body,
+		Doc:     doc,
+		Comment: c.LineComment(),
 	}, nil
 }
 
@@ -107,20 +139,45 @@ func (d *Default) FunctionBody(c Context) ([]ast.Instruction, error) {
 			break
 		}
 
-		instrNode, err := d.Instruction(c)
-		if err != nil {
-			return nil, err
+		instr, recovered := d.instructionSync(c, cur)
+		if recovered {
+			continue
 		}
+		instructions = append(instructions, instr)
+	}
 
-		instr, ok := instrNode.(ast.Instruction)
-		if !ok {
-			return nil, c.NewError("Expected instruction", cur.Position)
+	return instructions, nil
+}
+
+// instructionSync parses a single instruction, recovering from a Bailout
+// raised while parsing it. On recovery it resynchronizes with SyncStmt so
+// one malformed instruction doesn't prevent the rest of the function body
+// from being parsed.
+func (d *Default) instructionSync(c Context, cur *token.Token) (instr ast.Instruction, recovered bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(Bailout)
+			if !ok {
+				panic(r)
+			}
+			if b.Fatal {
+				panic(r)
+			}
+			SyncStmt(c)
+			recovered = true
 		}
+	}()
 
-		instructions = append(instructions, instr)
+	instrNode, err := d.Instruction(c)
+	if err != nil {
+		return ast.Instruction{}, true
 	}
 
-	return instructions, nil
+	got, ok := instrNode.(ast.Instruction)
+	if !ok {
+		c.NewError("Expected instruction", cur.Position)
+	}
+	return got, false
 }
 
 func (d *Default) FunctionArgs(c Context) ([]ast.FunctionArgument, error) {
@@ -170,12 +227,16 @@ func (d *Default) ReferenceToIdentifier(c Context) (ast.Node, error) {
 	if !ok {
 		return nil, c.NewError("Expected identifier", c.Current().Position)
 	}
+	if !c.Vocabulary().IsIdentifier(identifier.Literal) {
+		return nil, c.NewError(fmt.Sprintf("%v is not a valid identifier under the active vocabulary", identifier.Literal), identifier.Position)
+	}
 	return &ast.ReferenceToIdentifier{
 		Identifier: identifier.Literal,
 	}, nil
 }
 
 func (d *Default) Instruction(c Context) (ast.Node, error) {
+	doc := c.LeadComment()
 	name, ok := c.ExpectKind(token.Identifier)
 	if !ok {
 		return nil, c.NewError("Expected name of the instruction", c.Current().Position)
@@ -207,7 +268,7 @@ func (d *Default) Instruction(c Context) (ast.Node, error) {
 		}
 	}
 
-	return ast.Instruction{Name: name.Literal, Args: args}, nil
+	return ast.Instruction{Name: name.Literal, Args: args, Doc: doc, Comment: c.LineComment()}, nil
 }
 
 func (d *Default) Array(c Context) (ast.Node, error) {
@@ -53,6 +53,30 @@ type Context interface {
 	// The function automatically advances when returns true.
 	ExpectKind(kind token.Kind) (*token.Token, bool)
 
+	// LeadComment returns the comment group ending on the line immediately
+	// before the current token, or nil if there isn't one or ParseComments
+	// wasn't enabled. It's meant to be called before a node starts parsing.
+	LeadComment() *ast.CommentGroup
+
+	// LineComment returns the comment group made of a single comment on
+	// the line the previous token ended on, or nil if there isn't one or ParseComments
+	// wasn't enabled. It's meant to be called right after a node finishes
+	// parsing, e.g. after an instruction's terminating ';'.
+	LineComment() *ast.CommentGroup
+
+	// TopLevelOnly reports whether the parser's Mode has TopLevelOnly
+	// set, in which case mini.Default.TopLevel skips parsing each
+	// declaration's expression body instead of building it.
+	TopLevelOnly() bool
+
+	// Vocabulary returns the Vocabulary the parser was constructed with
+	// (parser.NewWithVocabulary), or token.DefaultVocabulary if none was
+	// set. Mini parsers consult it to decide whether an identifier-kind
+	// token is actually a valid identifier under the active instruction
+	// set, so an embedder's Vocabulary (reserved mnemonics, registers,
+	// directives) is enforced at parse time too, not just by the lexer.
+	Vocabulary() *token.Vocabulary
+
 	debug.Context
 }
 
@@ -0,0 +1,54 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mini
+
+import "github.com/dywoq/miniasm/pkg/token"
+
+// Bailout is the sentinel value Context.NewError panics with once it has
+// recorded an error. Recovering it and calling SyncStmt or SyncDecl lets a
+// caller resynchronize and keep parsing instead of aborting on the first
+// mistake, mirroring how go/parser recovers from its own bailout panic.
+//
+// Fatal is set once the error accumulation cap has been reached; a
+// recoverer should stop trying to resynchronize and let the panic
+// propagate all the way up to Parser.Do.
+type Bailout struct {
+	Fatal bool
+}
+
+// SyncDecl advances c to the next token that looks like the start of a
+// top-level declaration, discarding everything in between. It is used to
+// recover from a Bailout at the top level.
+func SyncDecl(c Context) {
+	for !c.IsEnd() {
+		if c.Current().Kind == token.Identifier {
+			return
+		}
+		c.Advance()
+	}
+}
+
+// SyncStmt advances c past the next ';' or '}', discarding everything in
+// between. It is used to recover from a Bailout inside a function body so
+// a single bad instruction doesn't swallow the rest of it.
+func SyncStmt(c Context) {
+	for !c.IsEnd() {
+		tok := c.Current()
+		c.Advance()
+		if tok.Literal == ";" || tok.Literal == "}" {
+			return
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+// Mode is a set of bit flags controlling how Parser.Do behaves, modeled
+// on go/parser.Mode.
+type Mode uint
+
+const (
+	// Trace makes Do emit an enter/leave line through the debug logger
+	// for every mini parser tried against the current token, so users can
+	// see which mini matched.
+	Trace Mode = 1 << iota
+
+	// TopLevelOnly makes Do return a tree containing every TopLevel
+	// identifier but skip parsing their expression bodies, which is
+	// enough to build an index of declared names without paying the
+	// cost of parsing every function body.
+	TopLevelOnly
+
+	// ParseComments makes Do collect comment tokens into
+	// ast.CommentGroups and attach them to the nodes they document,
+	// instead of discarding them.
+	ParseComments
+
+	// AllErrors disables the ErrorList cap, so Do keeps resynchronizing
+	// and reporting errors until EOF instead of bailing out once
+	// maxErrors is reached.
+	AllErrors
+)
+
+// Has reports whether flag is set in m.
+func (m Mode) Has(flag Mode) bool {
+	return m&flag != 0
+}
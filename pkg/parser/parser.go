@@ -15,7 +15,6 @@
 package parser
 
 import (
-	"fmt"
 	"io"
 	"log"
 	"sync"
@@ -45,6 +44,17 @@ type Parser struct {
 
 	// data
 	filename string
+	errors   ErrorList
+	fatal    bool
+	mode     Mode
+	fset     *token.FileSet
+	vocab    *token.Vocabulary
+
+	// comments
+	rawTokens    []*token.Token
+	comments     []*ast.CommentGroup
+	leadComments map[int]*ast.CommentGroup
+	lineComments map[int]*ast.CommentGroup
 }
 
 func New(tokens []*token.Token) *Parser {
@@ -60,10 +70,65 @@ func NewDebug(tokens []*token.Token, w io.Writer) *Parser {
 	return p
 }
 
+// NewWithMode creates a new Parser with mode controlling Do's behavior
+// (tracing, top-level-only parsing, comment attachment, the error cap).
+func NewWithMode(tokens []*token.Token, mode Mode) *Parser {
+	p := newBase(tokens)
+	p.mode = mode
+	return p
+}
+
+// NewWithFileSet creates a new Parser that carries fset (see FileSet).
+// Error filenames don't depend on it: a token whose Position carries a
+// *token.File (because it was resolved via File.Position or
+// FileSet.Position) reports its error under that File's name instead of
+// the filename passed to Do, whether or not the Parser has a FileSet.
+func NewWithFileSet(tokens []*token.Token, fset *token.FileSet) *Parser {
+	p := newBase(tokens)
+	p.fset = fset
+	return p
+}
+
+// FileSet returns the parser's FileSet, or nil if none was set.
+func (p *Parser) FileSet() *token.FileSet {
+	return p.fset
+}
+
+// SetFileSet sets the parser's FileSet. Panics if the parser is currently
+// working.
+func (p *Parser) SetFileSet(fset *token.FileSet) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.on.Load() {
+		panic("parser is on, can't set file set")
+	}
+	p.fset = fset
+}
+
+// NewWithVocabulary creates a new Parser whose mini parsers consult vocab
+// (via Context.Vocabulary) instead of assuming token.DefaultVocabulary,
+// so embedders can reuse this parser for a different instruction set
+// without forking the module. There's no equivalent lexer.New/pkg/lexer
+// wiring yet: pkg/lexer doesn't exist in this tree (main.go's import of
+// it is already broken at baseline), so vocabulary-aware lexing isn't
+// reachable from pkg/parser alone; only parsing-side checks (e.g.
+// mini.Default.ReferenceToIdentifier) see vocab.
+func NewWithVocabulary(tokens []*token.Token, vocab *token.Vocabulary) *Parser {
+	p := newBase(tokens)
+	p.vocab = vocab
+	return p
+}
+
+// Vocabulary returns the parser's Vocabulary, or nil if none was set.
+func (p *Parser) Vocabulary() *token.Vocabulary {
+	return p.vocab
+}
+
 func newBase(tokens []*token.Token) *Parser {
 	p := &Parser{}
 	p.on.Store(false)
 	p.pos = 0
+	p.rawTokens = tokens
 	p.tokens = tokens
 	p.debugOn.Store(false)
 	p.debugW = nil
@@ -78,9 +143,26 @@ func (p *Parser) SetTokens(tokens []*token.Token) {
 	if p.on.Load() {
 		panic("parser is on, can't set tokens")
 	}
+	p.rawTokens = tokens
 	p.tokens = tokens
 }
 
+// SetMode sets the parser's Mode. Panics if the parser is currently
+// working.
+func (p *Parser) SetMode(mode Mode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.on.Load() {
+		panic("parser is on, can't set mode")
+	}
+	p.mode = mode
+}
+
+// Mode returns the parser's current Mode.
+func (p *Parser) Mode() Mode {
+	return p.mode
+}
+
 // DebugSetWriter sets a new debugging writer.
 // Panics if the parser is currently working.
 func (p *Parser) DebugSetWriter(w io.Writer) {
@@ -136,8 +218,13 @@ func (c *context) Position() int {
 	return c.p.pos
 }
 
+// NewError records str as an error at pos and panics with mini.Bailout to
+// unwind out of whichever mini parser called it. It never returns normally;
+// the error return value exists only to satisfy mini.Context so mini
+// parsers can keep writing `return nil, c.NewError(...)`.
 func (c *context) NewError(str string, pos *token.Position) error {
-	return c.p.makeError(str, pos)
+	c.p.recordError(pos, str)
+	return nil
 }
 
 func (c *context) ExpectLiteral(lit string) (*token.Token, bool) {
@@ -149,6 +236,30 @@ func (c *context) ExpectLiteral(lit string) (*token.Token, bool) {
 	return tok, true
 }
 
+func (c *context) LeadComment() *ast.CommentGroup {
+	return c.p.leadComments[c.p.pos]
+}
+
+func (c *context) LineComment() *ast.CommentGroup {
+	if c.p.pos == 0 {
+		return nil
+	}
+	return c.p.lineComments[c.p.pos-1]
+}
+
+func (c *context) TopLevelOnly() bool {
+	return c.p.mode.Has(TopLevelOnly)
+}
+
+// Vocabulary returns the parser's Vocabulary, falling back to
+// token.DefaultVocabulary if NewWithVocabulary was never used.
+func (c *context) Vocabulary() *token.Vocabulary {
+	if c.p.vocab == nil {
+		return token.DefaultVocabulary
+	}
+	return c.p.vocab
+}
+
 func (c *context) ExpectKind(kind token.Kind) (*token.Token, bool) {
 	tok := c.p.tokens[c.p.pos]
 	if tok.Kind != kind {
@@ -176,8 +287,33 @@ func (c *context) DebugPrintln(a ...any) {
 	}
 }
 
-func (p *Parser) makeError(str string, pos *token.Position) error {
-	return fmt.Errorf("%v (%v:%v:%v)", str, p.filename, pos.Line, pos.Column)
+// recordError appends str at pos to the error list, capping it at
+// maxErrors, then panics with mini.Bailout so the mini parser that called
+// it unwinds instead of returning a half-built node. Once the cap is hit
+// the bailout is marked Fatal so recovery gives up resynchronizing.
+func (p *Parser) recordError(pos *token.Position, str string) {
+	capped := !p.mode.Has(AllErrors) && len(p.errors) >= maxErrors
+	if !capped {
+		p.errors.add(p.errorFilename(pos), pos, str)
+		capped = !p.mode.Has(AllErrors) && len(p.errors) >= maxErrors
+	}
+	panic(mini.Bailout{Fatal: capped})
+}
+
+// errorFilename returns the filename an error at pos should be reported
+// under: pos.File's name if pos came from a FileSet-tracked File
+// (possible whenever p.fset is in use), otherwise the filename Do was
+// called with.
+func (p *Parser) errorFilename(pos *token.Position) string {
+	if pos != nil && pos.File != nil {
+		return pos.File.Name()
+	}
+	return p.filename
+}
+
+// Errors returns every error accumulated by the most recent Do call.
+func (p *Parser) Errors() ErrorList {
+	return p.errors
 }
 
 func (p *Parser) Do(filename string) (*ast.Tree, error) {
@@ -197,21 +333,27 @@ func (p *Parser) Do(filename string) (*ast.Tree, error) {
 
 	if len(p.minis) == 0 {
 		c.DebugPrintln("No mini parsers detected")
-		return &ast.Tree{TopLevel: []ast.Node{}}, nil
+		return &ast.Tree{TopLevel: []ast.Node{}, Comments: p.comments}, nil
 	}
 
 	topLevel := []ast.Node{}
 	for !c.IsEnd() {
-		n, err := p.parse(c)
-		if err != nil {
-			return nil, err
+		n := p.parseSync(c)
+		if n != nil {
+			topLevel = append(topLevel, n)
+		}
+		if p.fatal {
+			c.DebugPrintln("Too many errors, aborting")
+			break
 		}
-		topLevel = append(topLevel, n)
 	}
 
+	p.errors.Sort()
+	p.errors.RemoveMultiples()
 	return &ast.Tree{
 		TopLevel: topLevel,
-	}, nil
+		Comments: p.comments,
+	}, p.errors.Err()
 }
 
 func (p *Parser) AppendParser(m mini.Parser) {
@@ -223,13 +365,41 @@ func (p *Parser) AppendParser(m mini.Parser) {
 	p.minis = append(p.minis, m)
 }
 
+// parseSync parses a single top-level node, recovering from a bailout
+// panic raised by context.NewError. On recovery it resynchronizes at the
+// next plausible declaration with mini.SyncDecl so one bad declaration
+// doesn't prevent the rest of the file from being parsed.
+func (p *Parser) parseSync(c *context) (node ast.Node) {
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(mini.Bailout)
+			if !ok {
+				panic(r)
+			}
+			node = nil
+			if b.Fatal {
+				p.fatal = true
+				return
+			}
+			mini.SyncDecl(c)
+		}
+	}()
+	n, _ := p.parse(c)
+	return n
+}
+
 func (p *Parser) parse(c *context) (ast.Node, error) {
 	// for getting position
 	tok := p.tokens[p.pos]
 
 	for _, parser := range p.minis {
-		c.DebugPrintln("Trying to parse")
+		if p.mode.Has(Trace) {
+			c.DebugPrintf("trace: enter %v at %v:%v\n", traceName(parser), tok.Position.Line, tok.Position.Column)
+		}
 		got, noMatch, err := parser(c)
+		if p.mode.Has(Trace) {
+			c.DebugPrintf("trace: leave %v (matched=%v)\n", traceName(parser), !noMatch)
+		}
 		if err != nil {
 			c.DebugPrintln("Encountered an error when parsing")
 			return nil, err
@@ -242,10 +412,19 @@ func (p *Parser) parse(c *context) (ast.Node, error) {
 		return got, nil
 
 	}
-	return nil, p.makeError("Unknown token", tok.Position)
+	return nil, c.NewError("Unknown token", tok.Position)
 }
 
 func (p *Parser) reset(filename string) {
 	p.pos = 0
 	p.filename = filename
+	p.errors = nil
+	p.fatal = false
+
+	if p.mode.Has(ParseComments) {
+		p.tokens, p.comments, p.leadComments, p.lineComments = extractComments(p.rawTokens)
+	} else {
+		p.tokens, _, _, _ = extractComments(p.rawTokens)
+		p.comments, p.leadComments, p.lineComments = nil, nil, nil
+	}
 }
@@ -0,0 +1,98 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dywoq/miniasm/pkg/parser"
+	"github.com/dywoq/miniasm/pkg/parser/mini"
+	"github.com/dywoq/miniasm/pkg/token"
+)
+
+// badTokens builds n top-level declarations of the form `nameK ;`, each
+// one failing to parse (mini.Default.Expression has no case for a bare
+// ';') so every round records exactly one error and SyncDecl resyncs at
+// the next round's identifier. Each round gets its own line so
+// ErrorList.RemoveMultiples doesn't collapse them.
+func badTokens(n int) []*token.Token {
+	tokens := make([]*token.Token, 0, n*2)
+	for i := 0; i < n; i++ {
+		tokens = append(tokens,
+			token.New(fmt.Sprintf("name%v", i), token.Identifier, &token.Position{Line: i*2 + 1}),
+			token.New(";", token.Separator, &token.Position{Line: i*2 + 2}),
+		)
+	}
+	return tokens
+}
+
+func TestDoErrorCap(t *testing.T) {
+	p := parser.New(badTokens(15))
+	d := &mini.Default{}
+	d.Append(p)
+
+	_, err := p.Do("test.mini")
+	if err == nil {
+		t.Fatal("Do() error = nil, want the error cap to be hit")
+	}
+
+	errs := p.Errors()
+	if len(errs) != 10 {
+		t.Fatalf("Errors() length = %v, want 10 (the cap)", len(errs))
+	}
+}
+
+func TestDoAllErrorsUncapped(t *testing.T) {
+	p := parser.NewWithMode(badTokens(15), parser.AllErrors)
+	d := &mini.Default{}
+	d.Append(p)
+
+	_, err := p.Do("test.mini")
+	if err == nil {
+		t.Fatal("Do() error = nil, want every bad declaration to report an error")
+	}
+
+	errs := p.Errors()
+	if len(errs) != 15 {
+		t.Fatalf("Errors() length = %v, want 15 (AllErrors disables the cap)", len(errs))
+	}
+}
+
+func TestDoResyncsPastBadDeclaration(t *testing.T) {
+	// One bad declaration followed by one good one: `bad ; good other`,
+	// where `good other` parses as a declaration whose expression is a
+	// reference to `other`.
+	tokens := []*token.Token{
+		token.New("bad", token.Identifier, &token.Position{Line: 1}),
+		token.New(";", token.Separator, &token.Position{Line: 1}),
+		token.New("good", token.Identifier, &token.Position{Line: 2}),
+		token.New("other", token.Identifier, &token.Position{Line: 2}),
+	}
+	p := parser.New(tokens)
+	d := &mini.Default{}
+	d.Append(p)
+
+	tree, err := p.Do("test.mini")
+	if err == nil {
+		t.Fatal("Do() error = nil, want the first declaration's error")
+	}
+	if len(p.Errors()) != 1 {
+		t.Fatalf("Errors() length = %v, want 1", len(p.Errors()))
+	}
+	if len(tree.TopLevel) != 1 {
+		t.Fatalf("TopLevel length = %v, want 1 (the declaration after resync)", len(tree.TopLevel))
+	}
+}
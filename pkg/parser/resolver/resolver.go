@@ -0,0 +1,138 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resolver links every ast.ReferenceToIdentifier in a parsed tree
+// to the ast.Object it names, turning miniasm's AST from a syntax dump
+// into something a semantic analyzer or codegen can use directly.
+package resolver
+
+import (
+	"fmt"
+
+	"github.com/dywoq/miniasm/pkg/ast"
+)
+
+// scope is a lexical scope used while resolving references. It chains to
+// a parent scope so a function's argument list can shadow top-level names
+// without losing access to the ones it doesn't shadow.
+type scope struct {
+	parent  *scope
+	objects map[string]*ast.Object
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent, objects: map[string]*ast.Object{}}
+}
+
+func (s *scope) lookup(name string) *ast.Object {
+	for cur := s; cur != nil; cur = cur.parent {
+		if obj, ok := cur.objects[name]; ok {
+			return obj
+		}
+	}
+	return nil
+}
+
+// errorList accumulates resolution failures instead of aborting on the
+// first one, so a single Resolve call reports every undeclared name.
+type errorList []error
+
+func (l errorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	out := msgs[0]
+	for _, m := range msgs[1:] {
+		out += "\n" + m
+	}
+	return out
+}
+
+func (l errorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Resolve walks tree, builds a top-level scope out of every
+// ast.TopLevel declaration, and links each ast.ReferenceToIdentifier to
+// the ast.Object it names. Function argument lists push a nested scope so
+// arguments shadow top-level names within the function body.
+//
+// It returns every resolution failure found (undeclared names, duplicate
+// top-level declarations, shadowed arguments within the same argument
+// list) rather than stopping at the first one.
+func Resolve(tree *ast.Tree) error {
+	top := newScope(nil)
+	var errs errorList
+
+	for _, n := range tree.TopLevel {
+		tl, ok := n.(*ast.TopLevel)
+		if !ok {
+			continue
+		}
+		if _, dup := top.objects[tl.Identifier]; dup {
+			errs = append(errs, fmt.Errorf("%v: top-level declaration redeclared", tl.Identifier))
+			continue
+		}
+		top.objects[tl.Identifier] = &ast.Object{Kind: ast.ObjTopLevel, Name: tl.Identifier, Decl: tl}
+	}
+
+	for _, n := range tree.TopLevel {
+		if tl, ok := n.(*ast.TopLevel); ok {
+			resolveNode(tl.Expression, top, &errs)
+		}
+	}
+
+	return errs.Err()
+}
+
+func resolveNode(n ast.Node, s *scope, errs *errorList) {
+	switch v := n.(type) {
+	case *ast.ReferenceToIdentifier:
+		obj := s.lookup(v.Identifier)
+		if obj == nil {
+			*errs = append(*errs, fmt.Errorf("%v: undeclared name", v.Identifier))
+			return
+		}
+		v.SetObj(obj)
+
+	case *ast.Function:
+		fnScope := newScope(s)
+		for _, arg := range v.Args {
+			if _, shadowed := fnScope.objects[arg.Name]; shadowed {
+				*errs = append(*errs, fmt.Errorf("%v: argument shadows another argument in the same list", arg.Name))
+				continue
+			}
+			fnScope.objects[arg.Name] = &ast.Object{Kind: ast.ObjFunctionArg, Name: arg.Name, Decl: v}
+		}
+		for _, instr := range v.Body {
+			for _, arg := range instr.Args {
+				resolveNode(arg, fnScope, errs)
+			}
+		}
+
+	case ast.Array:
+		for _, e := range v.Elements {
+			resolveNode(e, s, errs)
+		}
+
+	case ast.SpecialFunction:
+		for _, a := range v.Args {
+			resolveNode(a, s, errs)
+		}
+	}
+}
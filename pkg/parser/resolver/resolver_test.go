@@ -0,0 +1,91 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dywoq/miniasm/pkg/ast"
+	"github.com/dywoq/miniasm/pkg/parser/resolver"
+)
+
+// mini.Default.Array and mini.Default.SpecialFunction build these as
+// plain values, not pointers, so Resolve has to match the value forms
+// too or references inside an array/special-function call silently
+// never get checked.
+
+func TestResolveArrayElement(t *testing.T) {
+	tree := &ast.Tree{
+		TopLevel: []ast.Node{
+			&ast.TopLevel{
+				Identifier: "arr",
+				Expression: ast.Array{
+					Elements: []ast.Node{&ast.ReferenceToIdentifier{Identifier: "undeclared"}},
+				},
+			},
+		},
+	}
+
+	err := resolver.Resolve(tree)
+	if err == nil {
+		t.Fatal("Resolve() error = nil, want an undeclared name error for the array element")
+	}
+	if !strings.Contains(err.Error(), "undeclared name") {
+		t.Fatalf("Resolve() error = %v, want it to mention the undeclared name", err)
+	}
+}
+
+func TestResolveSpecialFunctionArg(t *testing.T) {
+	tree := &ast.Tree{
+		TopLevel: []ast.Node{
+			&ast.TopLevel{
+				Identifier: "sf",
+				Expression: ast.SpecialFunction{
+					Name: "at",
+					Args: []ast.Node{&ast.ReferenceToIdentifier{Identifier: "missing"}},
+				},
+			},
+		},
+	}
+
+	err := resolver.Resolve(tree)
+	if err == nil {
+		t.Fatal("Resolve() error = nil, want an undeclared name error for the special-function argument")
+	}
+	if !strings.Contains(err.Error(), "undeclared name") {
+		t.Fatalf("Resolve() error = %v, want it to mention the undeclared name", err)
+	}
+}
+
+func TestResolveArrayElementLinksDeclaredName(t *testing.T) {
+	ref := &ast.ReferenceToIdentifier{Identifier: "foo"}
+	tree := &ast.Tree{
+		TopLevel: []ast.Node{
+			&ast.TopLevel{Identifier: "foo"},
+			&ast.TopLevel{
+				Identifier: "arr",
+				Expression: ast.Array{Elements: []ast.Node{ref}},
+			},
+		},
+	}
+
+	if err := resolver.Resolve(tree); err != nil {
+		t.Fatalf("Resolve() error = %v, want nil", err)
+	}
+	if ref.Obj() == nil {
+		t.Fatal("Obj() = nil, want the array element resolved against the declared top-level")
+	}
+}
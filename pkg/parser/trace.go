@@ -0,0 +1,40 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"reflect"
+	"runtime"
+
+	"github.com/dywoq/miniasm/pkg/parser/mini"
+)
+
+// traceName returns a short, readable name for a mini parser function,
+// its runtime name after the last '/' (for the method value d.TopLevel,
+// "mini.(*Default).TopLevel-fm"), for use in Trace mode debug output.
+func traceName(p mini.Parser) string {
+	pc := reflect.ValueOf(p).Pointer()
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '/' {
+			return name[i+1:]
+		}
+	}
+	return name
+}
@@ -0,0 +1,333 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocessor
+
+import (
+	"github.com/dywoq/miniasm/token"
+)
+
+// Default contains the default MiniASM directives.
+type Default struct {
+}
+
+func (d *Default) Append(a Appender) {
+	a.AppendDirective(d.Define)
+	a.AppendDirective(d.Include)
+	a.AppendDirective(d.Ifdef)
+	a.AppendDirective(d.Ifndef)
+	a.AppendDirective(d.Endif)
+	a.AppendDirective(d.MacroDef)
+	a.AppendDirective(d.DefineExpand)
+	a.AppendDirective(d.MacroCall)
+}
+
+// Define implements `%define NAME value`: value is every token that
+// follows NAME on the same source line, substituted in wherever NAME is
+// later referenced as a bare identifier.
+func (d *Default) Define(c Context) ([]*token.Token, bool, error) {
+	cur := c.Current()
+	if cur == nil || cur.Literal != "%define" {
+		return nil, true, nil
+	}
+	c.Advance()
+
+	name, ok := expectIdentifier(c)
+	if !ok {
+		return nil, false, c.NewError("Expected identifier after %define", positionOf(c))
+	}
+
+	line := name.Position.Line
+	value := []*token.Token{}
+	for !c.IsEnd() && c.Current().Position.Line == line {
+		value = append(value, c.Current())
+		c.Advance()
+	}
+
+	c.Define(name.Literal, value)
+	return nil, false, nil
+}
+
+// Include implements `%include "path"`: it resolves path via the
+// preprocessor's IncludeResolver and splices in its fully expanded
+// tokens.
+func (d *Default) Include(c Context) ([]*token.Token, bool, error) {
+	cur := c.Current()
+	if cur == nil || cur.Literal != "%include" {
+		return nil, true, nil
+	}
+	at := cur.Position
+	c.Advance()
+
+	path := c.Current()
+	if path == nil || path.Kind != token.String {
+		return nil, false, c.NewError("Expected a quoted path after %include", at)
+	}
+	c.Advance()
+
+	tokens, err := c.Include(path.Literal, at)
+	if err != nil {
+		return nil, false, c.NewError(err.Error(), at)
+	}
+	return tokens, false, nil
+}
+
+// Ifdef implements `%ifdef NAME ... %endif`, keeping the body only if
+// NAME is defined.
+func (d *Default) Ifdef(c Context) ([]*token.Token, bool, error) {
+	return d.ifBranch(c, "%ifdef", true)
+}
+
+// Ifndef implements `%ifndef NAME ... %endif`, keeping the body only if
+// NAME isn't defined.
+func (d *Default) Ifndef(c Context) ([]*token.Token, bool, error) {
+	return d.ifBranch(c, "%ifndef", false)
+}
+
+func (d *Default) ifBranch(c Context, directive string, wantDefined bool) ([]*token.Token, bool, error) {
+	cur := c.Current()
+	if cur == nil || cur.Literal != directive {
+		return nil, true, nil
+	}
+	pos := cur.Position
+	c.Advance()
+
+	name, ok := expectIdentifier(c)
+	if !ok {
+		return nil, false, c.NewError("Expected identifier after "+directive, pos)
+	}
+
+	_, defined := c.Lookup(name.Literal)
+	if _, isMacro := c.LookupMacro(name.Literal); isMacro {
+		defined = true
+	}
+	if defined == wantDefined {
+		// Condition true: leave the body in place for normal expansion,
+		// and let Endif consume the matching %endif when it's reached.
+		return nil, false, nil
+	}
+	if err := skipToEndif(c); err != nil {
+		return nil, false, err
+	}
+	return nil, false, nil
+}
+
+// Endif consumes a %endif left over from a true %ifdef/%ifndef branch.
+func (d *Default) Endif(c Context) ([]*token.Token, bool, error) {
+	cur := c.Current()
+	if cur == nil || cur.Literal != "%endif" {
+		return nil, true, nil
+	}
+	c.Advance()
+	return nil, false, nil
+}
+
+// skipToEndif advances c past a false %ifdef/%ifndef branch up to and
+// including its matching %endif, tracking nested %ifdef/%ifndef/%endif
+// so a nested conditional doesn't end the skip early.
+func skipToEndif(c Context) error {
+	depth := 1
+	for !c.IsEnd() {
+		cur := c.Current()
+		switch cur.Literal {
+		case "%ifdef", "%ifndef":
+			depth++
+		case "%endif":
+			depth--
+			if depth == 0 {
+				c.Advance()
+				return nil
+			}
+		}
+		c.Advance()
+	}
+	return c.NewError("Unterminated %ifdef/%ifndef, expected %endif", nil)
+}
+
+// MacroDef implements `%macro NAME(params) ... %endmacro`, registering
+// NAME as a function-like macro.
+func (d *Default) MacroDef(c Context) ([]*token.Token, bool, error) {
+	cur := c.Current()
+	if cur == nil || cur.Literal != "%macro" {
+		return nil, true, nil
+	}
+	pos := cur.Position
+	c.Advance()
+
+	name, ok := expectIdentifier(c)
+	if !ok {
+		return nil, false, c.NewError("Expected macro name after %macro", pos)
+	}
+
+	params, err := parseParamList(c)
+	if err != nil {
+		return nil, false, err
+	}
+
+	body := []*token.Token{}
+	for {
+		if c.IsEnd() {
+			return nil, false, c.NewError("Unterminated %macro, expected %endmacro", pos)
+		}
+		if c.Current().Literal == "%endmacro" {
+			c.Advance()
+			break
+		}
+		body = append(body, c.Current())
+		c.Advance()
+	}
+
+	c.DefineMacro(name.Literal, Macro{Params: params, Body: body})
+	return nil, false, nil
+}
+
+// parseParamList parses a parenthesized, comma-separated identifier list
+// such as `(a, b)`.
+func parseParamList(c Context) ([]string, error) {
+	if cur := c.Current(); cur == nil || cur.Literal != "(" {
+		return nil, c.NewError("Expected '(' in macro parameter list", positionOf(c))
+	}
+	c.Advance()
+
+	params := []string{}
+	for {
+		cur := c.Current()
+		if cur != nil && cur.Literal == ")" {
+			c.Advance()
+			break
+		}
+		param, ok := expectIdentifier(c)
+		if !ok {
+			return nil, c.NewError("Expected parameter name", positionOf(c))
+		}
+		params = append(params, param.Literal)
+
+		cur = c.Current()
+		if cur != nil && cur.Literal == "," {
+			c.Advance()
+			continue
+		}
+		if cur != nil && cur.Literal == ")" {
+			c.Advance()
+			break
+		}
+		return nil, c.NewError("Expected ',' or ')' in macro parameter list", positionOf(c))
+	}
+	return params, nil
+}
+
+// DefineExpand substitutes a bare identifier previously registered by
+// Define with the tokens it was defined as, e.g. `%define FOO 42`
+// followed later by a reference to FOO. It's ordered before MacroCall so
+// a %define'd name that happens to collide with a macro name still
+// expands as a %define here first.
+func (d *Default) DefineExpand(c Context) ([]*token.Token, bool, error) {
+	cur := c.Current()
+	if cur == nil || cur.Kind != token.Identifier {
+		return nil, true, nil
+	}
+	value, ok := c.Lookup(cur.Literal)
+	if !ok {
+		return nil, true, nil
+	}
+	at := cur.Position
+	c.Advance()
+	return originate(value, at), false, nil
+}
+
+// MacroCall recognizes an invocation of a macro registered by MacroDef,
+// e.g. `NAME(arg1, arg2)`, and expands it in place.
+func (d *Default) MacroCall(c Context) ([]*token.Token, bool, error) {
+	cur := c.Current()
+	if cur == nil || cur.Kind != token.Identifier {
+		return nil, true, nil
+	}
+	m, ok := c.LookupMacro(cur.Literal)
+	if !ok {
+		return nil, true, nil
+	}
+	at := cur.Position
+	c.Advance()
+
+	args, err := parseArgList(c)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := c.CountMacroExpansion(); err != nil {
+		return nil, false, c.NewError(err.Error(), at)
+	}
+
+	expanded, err := c.ExpandMacro(m, args, at)
+	if err != nil {
+		return nil, false, err
+	}
+	return expanded, false, nil
+}
+
+// parseArgList parses a parenthesized, comma-separated argument list,
+// where each argument is every token between its surrounding ',' or
+// enclosing '('/')'.
+func parseArgList(c Context) ([][]*token.Token, error) {
+	if cur := c.Current(); cur == nil || cur.Literal != "(" {
+		return nil, c.NewError("Expected '(' in macro call", positionOf(c))
+	}
+	c.Advance()
+
+	args := [][]*token.Token{}
+	arg := []*token.Token{}
+	depth := 0
+	for {
+		cur := c.Current()
+		if cur == nil {
+			return nil, c.NewError("Unterminated macro call, expected ')'", positionOf(c))
+		}
+		switch {
+		case cur.Literal == "(" || cur.Literal == "[":
+			depth++
+		case cur.Literal == ")" && depth == 0:
+			args = append(args, arg)
+			c.Advance()
+			return args, nil
+		case (cur.Literal == ")" || cur.Literal == "]") && depth > 0:
+			depth--
+		case cur.Literal == "," && depth == 0:
+			args = append(args, arg)
+			arg = []*token.Token{}
+			c.Advance()
+			continue
+		}
+		arg = append(arg, cur)
+		c.Advance()
+	}
+}
+
+// expectIdentifier consumes and returns the current token if it's a
+// token.Identifier, or reports false without advancing.
+func expectIdentifier(c Context) (*token.Token, bool) {
+	cur := c.Current()
+	if cur == nil || cur.Kind != token.Identifier {
+		return nil, false
+	}
+	c.Advance()
+	return cur, true
+}
+
+// positionOf returns the current token's position, or nil past EOF.
+func positionOf(c Context) *token.Position {
+	if cur := c.Current(); cur != nil {
+		return cur.Position
+	}
+	return nil
+}
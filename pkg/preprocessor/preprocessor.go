@@ -0,0 +1,475 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preprocessor expands %define, %include, %ifdef/%ifndef/%endif
+// and function-like %macro directives over the []*token.Token stream
+// produced by lexer.Do, before it reaches parser/mini.
+package preprocessor
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dywoq/miniasm/token"
+)
+
+// maxIncludeDepth caps how deeply %include files may nest, and
+// maxMacroExpansions caps how many macro invocations a single Do call
+// may expand in total, so a cyclic include or recursive macro fails with
+// an error instead of hanging or exhausting memory.
+const (
+	maxIncludeDepth    = 32
+	maxMacroExpansions = 1000
+)
+
+// Macro is a function-like macro registered by %macro NAME(params) ...
+// %endmacro: invoking NAME(args) substitutes each parameter in Body with
+// its corresponding argument's tokens.
+type Macro struct {
+	Params []string
+	Body   []*token.Token
+}
+
+// Directive expands the directive starting at the preprocessor's current
+// position into zero or more replacement tokens, advancing past whatever
+// it consumed. It mirrors mini.Parser: noMatch reports whether the token
+// at the current position doesn't match the directive's requirements.
+//
+// Returns true for noMatch if the token doesn't match the directive's
+// requirements, in which case the preprocessor tries the next registered
+// directive.
+type Directive func(c Context) (tokens []*token.Token, noMatch bool, err error)
+
+// Appender defines an interface for appending directives.
+type Appender interface {
+	AppendDirective(d Directive)
+}
+
+// IncludeResolver resolves a %include path to the token stream lexer.Do
+// produced for it, plus the filename to record for cycle detection and
+// to attribute further nested directives to.
+type IncludeResolver interface {
+	Resolve(path string) (tokens []*token.Token, filename string, err error)
+}
+
+// Context is the interface directives use to inspect and rewrite the
+// token stream, manage %define/%macro state, and descend into %include.
+type Context interface {
+	// IsEnd reports whether the preprocessor has reached the end of the
+	// current token stream.
+	IsEnd() bool
+
+	// Current returns the current token, or nil at the end.
+	Current() *token.Token
+
+	// Advance advances to the next token.
+	Advance()
+
+	// Define registers name to expand to replacement wherever it's
+	// referenced afterwards.
+	Define(name string, replacement []*token.Token)
+
+	// Lookup returns the tokens name is defined as, and whether it's
+	// defined at all.
+	Lookup(name string) ([]*token.Token, bool)
+
+	// DefineMacro registers a function-like macro.
+	DefineMacro(name string, m Macro)
+
+	// LookupMacro returns the macro registered as name, and whether it's
+	// defined at all.
+	LookupMacro(name string) (Macro, bool)
+
+	// ExpandMacro substitutes m's parameters with args in m's body,
+	// returning the substituted tokens. It's a plain helper; it doesn't
+	// touch the expansion counter or the token stream.
+	ExpandMacro(m Macro, args [][]*token.Token, at *token.Position) ([]*token.Token, error)
+
+	// CountMacroExpansion records one macro invocation having been
+	// expanded, returning an error once maxMacroExpansions is exceeded.
+	CountMacroExpansion() error
+
+	// Include resolves path via the configured IncludeResolver and
+	// returns its fully expanded tokens. at is the %include directive's
+	// own position, recorded as the Origin of every token the included
+	// file contributes. Returns an error if path is already being
+	// included (a cycle), nesting is too deep, or the resolver fails.
+	Include(path string, at *token.Position) ([]*token.Token, error)
+
+	// NewError builds an error positioned at pos.
+	NewError(str string, pos *token.Position) error
+
+	// DebugPrintf writes a debug formatted message.
+	DebugPrintf(format string, a ...any)
+
+	// DebugPrint writes a debug message without newline.
+	DebugPrint(a ...any)
+
+	// DebugPrintln writes a debug message with newline.
+	DebugPrintln(a ...any)
+}
+
+// Preprocessor expands directives over a token stream. Each registered
+// Directive has only one responsibility, the same modular design as
+// lexer.Tokenizer and mini.Parser.
+type Preprocessor struct {
+	// base
+	tokens []*token.Token
+	pos    int
+	on     atomic.Bool
+
+	// debug
+	debugW      io.Writer
+	debugOn     atomic.Bool
+	debugLogger *log.Logger
+
+	// directives
+	directives []Directive
+
+	// mutex
+	mu sync.Mutex
+
+	// data
+	filename     string
+	resolver     IncludeResolver
+	defines      map[string][]*token.Token
+	macros       map[string]Macro
+	includeStack []string
+	expansions   int
+}
+
+// New creates a new Preprocessor over tokens, with debugging automatically
+// turned off. resolver may be nil if the source never uses %include.
+func New(tokens []*token.Token, resolver IncludeResolver) *Preprocessor {
+	p := newBase(tokens, resolver)
+	p.debugOn.Store(false)
+	p.debugW = nil
+	return p
+}
+
+// NewDebug works the same as New, but requires a debug writer and
+// automatically turns debug mode on.
+func NewDebug(tokens []*token.Token, resolver IncludeResolver, w io.Writer) *Preprocessor {
+	p := newBase(tokens, resolver)
+	p.debugOn.Store(true)
+	p.debugW = w
+	p.debugLogger = log.New(p.debugW, "", log.Default().Flags())
+	return p
+}
+
+func newBase(tokens []*token.Token, resolver IncludeResolver) *Preprocessor {
+	p := &Preprocessor{}
+	p.tokens = tokens
+	p.resolver = resolver
+	p.defines = map[string][]*token.Token{}
+	p.macros = map[string]Macro{}
+	p.mu = sync.Mutex{}
+	p.on.Store(false)
+	return p
+}
+
+// SetTokens sets a new token stream to expand.
+// Panics if the preprocessor is currently working.
+func (p *Preprocessor) SetTokens(tokens []*token.Token) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.on.Load() {
+		panic("preprocessor is on, can't set tokens")
+	}
+	p.tokens = tokens
+}
+
+// SetResolver sets the IncludeResolver used to resolve %include paths.
+// Panics if the preprocessor is currently working.
+func (p *Preprocessor) SetResolver(resolver IncludeResolver) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.on.Load() {
+		panic("preprocessor is on, can't set resolver")
+	}
+	p.resolver = resolver
+}
+
+// DebugSetWriter sets a new debugging writer.
+// Panics if the preprocessor is currently working.
+func (p *Preprocessor) DebugSetWriter(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.on.Load() {
+		panic("preprocessor is on, can't set debug writer")
+	}
+	p.debugW = w
+	if p.debugLogger == nil {
+		p.debugLogger = log.New(p.debugW, "", log.Default().Flags())
+	}
+}
+
+// DebugSetMode sets a debugging mode to b.
+// Panics if the preprocessor is currently working.
+func (p *Preprocessor) DebugSetMode(b bool) {
+	if p.on.Load() {
+		panic("preprocessor is on, can't set debug mode")
+	}
+	p.debugOn.Store(b)
+}
+
+// DebugOn returns true if debugging is on.
+func (p *Preprocessor) DebugOn() bool {
+	return p.debugOn.Load()
+}
+
+// AppendDirective appends a new directive to the preprocessor.
+// Panics if the preprocessor is currently working.
+func (p *Preprocessor) AppendDirective(d Directive) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.on.Load() {
+		panic("preprocessor is on, can't append directive")
+	}
+	p.directives = append(p.directives, d)
+}
+
+// implements Context
+type context struct {
+	p *Preprocessor
+}
+
+func (c *context) IsEnd() bool {
+	return c.p.pos >= len(c.p.tokens)
+}
+
+func (c *context) Current() *token.Token {
+	if c.IsEnd() {
+		return nil
+	}
+	return c.p.tokens[c.p.pos]
+}
+
+func (c *context) Advance() {
+	if c.IsEnd() {
+		return
+	}
+	c.p.pos++
+}
+
+func (c *context) Define(name string, replacement []*token.Token) {
+	c.p.defines[name] = replacement
+}
+
+func (c *context) Lookup(name string) ([]*token.Token, bool) {
+	tokens, ok := c.p.defines[name]
+	return tokens, ok
+}
+
+func (c *context) DefineMacro(name string, m Macro) {
+	c.p.macros[name] = m
+}
+
+func (c *context) LookupMacro(name string) (Macro, bool) {
+	m, ok := c.p.macros[name]
+	return m, ok
+}
+
+func (c *context) ExpandMacro(m Macro, args [][]*token.Token, at *token.Position) ([]*token.Token, error) {
+	if len(args) != len(m.Params) {
+		return nil, c.NewError(fmt.Sprintf("macro expects %v argument(s), got %v", len(m.Params), len(args)), at)
+	}
+
+	subst := make(map[string][]*token.Token, len(m.Params))
+	for i, param := range m.Params {
+		subst[param] = args[i]
+	}
+
+	out := make([]*token.Token, 0, len(m.Body))
+	for _, tok := range m.Body {
+		if replacement, ok := subst[tok.Literal]; ok && tok.Kind == token.Identifier {
+			out = append(out, originate(replacement, at)...)
+			continue
+		}
+		out = append(out, originate([]*token.Token{tok}, at)...)
+	}
+	return out, nil
+}
+
+func (c *context) CountMacroExpansion() error {
+	c.p.expansions++
+	if c.p.expansions > maxMacroExpansions {
+		return fmt.Errorf("preprocessor: macro expansion depth exceeds max of %v (possible recursive macro)", maxMacroExpansions)
+	}
+	return nil
+}
+
+func (c *context) Include(path string, at *token.Position) ([]*token.Token, error) {
+	return c.p.include(path, at)
+}
+
+func (c *context) NewError(str string, pos *token.Position) error {
+	if pos == nil {
+		return fmt.Errorf("%v: %v", c.p.filename, str)
+	}
+	return fmt.Errorf("%v (at %v:%v:%v)", str, c.p.filename, pos.Line, pos.Column)
+}
+
+func (c *context) DebugPrintf(format string, a ...any) {
+	if c.p.DebugOn() {
+		c.p.debugLogger.Printf(format, a...)
+	}
+}
+
+func (c *context) DebugPrint(a ...any) {
+	if c.p.DebugOn() {
+		c.p.debugLogger.Print(a...)
+	}
+}
+
+func (c *context) DebugPrintln(a ...any) {
+	if c.p.DebugOn() {
+		c.p.debugLogger.Println(a...)
+	}
+}
+
+// Do runs the preprocessor over the configured token stream and returns
+// the fully expanded result.
+//
+// Does nothing but return the input tokens verbatim if there are no
+// registered directives.
+func (p *Preprocessor) Do(filename string) ([]*token.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.on.Load() {
+		panic("preprocessor is on, can't do")
+	}
+
+	p.pos = 0
+	p.filename = filename
+	p.includeStack = []string{filename}
+	p.expansions = 0
+
+	c := &context{p}
+	c.DebugPrintln("Starting preprocessor...")
+	p.on.Store(true)
+	defer func() {
+		p.on.Store(false)
+		c.DebugPrintln("Preprocessor ended")
+	}()
+
+	if len(p.directives) == 0 {
+		c.DebugPrintln("No directives detected")
+		return p.tokens, nil
+	}
+	return p.expand()
+}
+
+// expand drains p.tokens from p.pos to the end, dispatching each
+// position to the registered directives and splicing in whatever tokens
+// a matching directive returns so they're themselves scanned for further
+// directives (a %macro body invoking another macro, an %include'd file
+// %include-ing something else, and so on).
+func (p *Preprocessor) expand() ([]*token.Token, error) {
+	c := &context{p}
+	out := []*token.Token{}
+	for !c.IsEnd() {
+		expanded, matched, err := p.dispatch(c)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			out = append(out, c.Current())
+			c.Advance()
+			continue
+		}
+		if len(expanded) > 0 {
+			p.splice(expanded)
+		}
+	}
+	return out, nil
+}
+
+func (p *Preprocessor) dispatch(c Context) (tokens []*token.Token, matched bool, err error) {
+	for _, d := range p.directives {
+		tokens, noMatch, err := d(c)
+		if err != nil {
+			return nil, true, err
+		}
+		if noMatch {
+			continue
+		}
+		return tokens, true, nil
+	}
+	return nil, false, nil
+}
+
+// splice inserts tokens into the stream at the preprocessor's current
+// position.
+func (p *Preprocessor) splice(tokens []*token.Token) {
+	head := append([]*token.Token{}, p.tokens[:p.pos]...)
+	tail := p.tokens[p.pos:]
+	p.tokens = append(append(head, tokens...), tail...)
+}
+
+// include resolves path, guarding against cycles and excessive nesting,
+// and fully expands its tokens before returning them, so a %include'd
+// file's own directives never leak unexpanded into the includer.
+func (p *Preprocessor) include(path string, at *token.Position) ([]*token.Token, error) {
+	for _, seen := range p.includeStack {
+		if seen == path {
+			return nil, fmt.Errorf("preprocessor: cyclic %%include of %q (include chain: %v)", path, strings.Join(p.includeStack, " -> "))
+		}
+	}
+	if len(p.includeStack) >= maxIncludeDepth {
+		return nil, fmt.Errorf("preprocessor: %%include nesting exceeds max depth of %v", maxIncludeDepth)
+	}
+	if p.resolver == nil {
+		return nil, fmt.Errorf("preprocessor: %%include %q: no IncludeResolver configured", path)
+	}
+
+	tokens, filename, err := p.resolver.Resolve(path)
+	if err != nil {
+		return nil, fmt.Errorf("preprocessor: %%include %q: %w", path, err)
+	}
+	tokens = originate(tokens, at)
+
+	savedTokens, savedPos, savedFilename := p.tokens, p.pos, p.filename
+	p.tokens, p.pos, p.filename = tokens, 0, filename
+	p.includeStack = append(p.includeStack, path)
+
+	out, err := p.expand()
+
+	p.includeStack = p.includeStack[:len(p.includeStack)-1]
+	p.tokens, p.pos, p.filename = savedTokens, savedPos, savedFilename
+
+	return out, err
+}
+
+// originate returns a copy of tokens whose Position.Origin is set to at,
+// for every token that doesn't already carry one, so re-including or
+// re-expanding an already-originated token keeps its original chain
+// instead of overwriting it with a closer but less useful site.
+func originate(tokens []*token.Token, at *token.Position) []*token.Token {
+	out := make([]*token.Token, len(tokens))
+	for i, tok := range tokens {
+		if tok.Position == nil || tok.Position.Origin != nil || at == nil {
+			out[i] = tok
+			continue
+		}
+		pos := *tok.Position
+		pos.Origin = at
+		out[i] = &token.Token{Literal: tok.Literal, Kind: tok.Kind, Position: &pos}
+	}
+	return out
+}
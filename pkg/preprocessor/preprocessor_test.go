@@ -0,0 +1,208 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocessor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dywoq/miniasm/lexer"
+	"github.com/dywoq/miniasm/lexer/tokenizer"
+	"github.com/dywoq/miniasm/token"
+)
+
+func lexTokens(t *testing.T, src, filename string) []*token.Token {
+	t.Helper()
+	l, err := lexer.New(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("lexer.New() error = %v", err)
+	}
+	d := &tokenizer.Default{}
+	d.Append(l)
+	tokens, err := l.Do(filename)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	return tokens
+}
+
+// mapResolver resolves %include paths to sources registered in srcs,
+// lexing each one on demand the same way a real IncludeResolver would
+// lex a file it reads off disk.
+type mapResolver struct {
+	t    *testing.T
+	srcs map[string]string
+}
+
+func (r mapResolver) Resolve(path string) ([]*token.Token, string, error) {
+	src, ok := r.srcs[path]
+	if !ok {
+		return nil, "", &includeNotFoundError{path}
+	}
+	return lexTokens(r.t, src, path), path, nil
+}
+
+type includeNotFoundError struct{ path string }
+
+func (e *includeNotFoundError) Error() string {
+	return "no such include: " + e.path
+}
+
+func TestDefineAndLookup(t *testing.T) {
+	tokens := lexTokens(t, `%define FOO 42`, "test.asm")
+	p := newBase(tokens, nil)
+	c := &context{p}
+
+	d := &Default{}
+	_, noMatch, err := d.Define(c)
+	if err != nil {
+		t.Fatalf("Define() error = %v", err)
+	}
+	if noMatch {
+		t.Fatal("Define() noMatch = true, want false")
+	}
+
+	value, ok := c.Lookup("FOO")
+	if !ok {
+		t.Fatal("Lookup(FOO) ok = false, want true")
+	}
+	if len(value) != 1 || value[0].Literal != "42" {
+		t.Fatalf("Lookup(FOO) = %v, want a single token with literal 42", value)
+	}
+}
+
+func TestDoDefineExpansion(t *testing.T) {
+	tokens := lexTokens(t, "%define FOO 42\nFOO", "test.asm")
+
+	p := New(tokens, nil)
+	d := &Default{}
+	d.Append(p)
+
+	out, err := p.Do("test.asm")
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(out) != 1 || out[0].Literal != "42" {
+		t.Fatalf("Do() tokens = %v, want a single token with literal 42", out)
+	}
+}
+
+// mtok builds a token by hand rather than through the lexer: '(' and
+// ')' have no tokenizer registered in lexer/tokenizer.Default (the
+// lexer can only ever produce them if an embedder adds one), but
+// MacroDef/MacroCall's parameter and argument lists depend on them, so
+// macro-shaped tests have to construct their token stream directly.
+func mtok(literal string, kind token.Kind) *token.Token {
+	return token.New(literal, kind, &token.Position{Line: 1})
+}
+
+func TestDoMacroExpansion(t *testing.T) {
+	// %macro ADD(a, b) a b %endmacro
+	// ADD(x, y)
+	tokens := []*token.Token{
+		mtok("%macro", token.Directive),
+		mtok("ADD", token.Identifier),
+		mtok("(", token.Separator),
+		mtok("a", token.Identifier),
+		mtok(",", token.Separator),
+		mtok("b", token.Identifier),
+		mtok(")", token.Separator),
+		mtok("a", token.Identifier),
+		mtok("b", token.Identifier),
+		mtok("%endmacro", token.Directive),
+		mtok("ADD", token.Identifier),
+		mtok("(", token.Separator),
+		mtok("x", token.Identifier),
+		mtok(",", token.Separator),
+		mtok("y", token.Identifier),
+		mtok(")", token.Separator),
+	}
+
+	p := New(tokens, nil)
+	d := &Default{}
+	d.Append(p)
+
+	out, err := p.Do("test.asm")
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	literals := make([]string, len(out))
+	for i, tok := range out {
+		literals[i] = tok.Literal
+	}
+	want := []string{"x", "y"}
+	if len(literals) != len(want) {
+		t.Fatalf("Do() tokens = %v, want %v", literals, want)
+	}
+	for i := range want {
+		if literals[i] != want[i] {
+			t.Fatalf("Do() tokens = %v, want %v", literals, want)
+		}
+	}
+}
+
+func TestDoCyclicInclude(t *testing.T) {
+	resolver := mapResolver{t: t, srcs: map[string]string{
+		"a.asm": `%include "b.asm"`,
+		"b.asm": `%include "a.asm"`,
+	}}
+
+	tokens := lexTokens(t, `%include "a.asm"`, "main.asm")
+	p := New(tokens, resolver)
+	d := &Default{}
+	d.Append(p)
+
+	_, err := p.Do("main.asm")
+	if err == nil {
+		t.Fatal("Do() error = nil, want a cyclic include error")
+	}
+	if !strings.Contains(err.Error(), "cyclic") {
+		t.Fatalf("Do() error = %v, want it to mention a cyclic include", err)
+	}
+}
+
+func TestDoMacroRecursionCap(t *testing.T) {
+	// %macro REC(x) REC(x) %endmacro
+	// REC(1)
+	tokens := []*token.Token{
+		mtok("%macro", token.Directive),
+		mtok("REC", token.Identifier),
+		mtok("(", token.Separator),
+		mtok("x", token.Identifier),
+		mtok(")", token.Separator),
+		mtok("REC", token.Identifier),
+		mtok("(", token.Separator),
+		mtok("x", token.Identifier),
+		mtok(")", token.Separator),
+		mtok("%endmacro", token.Directive),
+		mtok("REC", token.Identifier),
+		mtok("(", token.Separator),
+		mtok("1", token.Number),
+		mtok(")", token.Separator),
+	}
+
+	p := New(tokens, nil)
+	d := &Default{}
+	d.Append(p)
+
+	_, err := p.Do("test.asm")
+	if err == nil {
+		t.Fatal("Do() error = nil, want a macro expansion cap error")
+	}
+	if !strings.Contains(err.Error(), "macro expansion depth exceeds max") {
+		t.Fatalf("Do() error = %v, want it to mention the macro expansion depth cap", err)
+	}
+}
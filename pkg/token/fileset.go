@@ -0,0 +1,138 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import "sync"
+
+// Pos is an opaque offset into a FileSet's global address space,
+// analogous to go/token.Pos. The zero Pos is NoPos, meaning "no position".
+type Pos int
+
+// NoPos means "no position"; it is never a valid Pos returned by a File
+// added to a FileSet, since NewFileSet starts the first file at base 1,
+// leaving offset 0 as a gap.
+const NoPos Pos = 0
+
+// File tracks the position information for a single file added to a
+// FileSet: its name, its base offset in the set's global address space,
+// and a cumulative table of line-start offsets built up via AddLine.
+type File struct {
+	name string
+	base int
+	size int
+
+	mu    sync.Mutex
+	lines []int // file-relative offsets of each line's first byte; lines[0] == 0
+}
+
+// Name returns the filename the File was added under.
+func (f *File) Name() string { return f.name }
+
+// Base returns the File's base offset in its FileSet's address space.
+func (f *File) Base() int { return f.base }
+
+// Size returns the size in bytes the File was added with.
+func (f *File) Size() int { return f.size }
+
+// AddLine records the file-relative offset of the start of a new line.
+// Offsets must be added in increasing order and fall within [0, Size()];
+// anything else is silently ignored.
+func (f *File) AddLine(offset int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if offset < 0 || offset > f.size {
+		return
+	}
+	if n := len(f.lines); n == 0 || f.lines[n-1] < offset {
+		f.lines = append(f.lines, offset)
+	}
+}
+
+// Pos returns the global Pos of the file-relative byte offset.
+func (f *File) Pos(offset int) Pos {
+	return Pos(f.base + offset)
+}
+
+// Position resolves p, which must belong to this File, to a
+// line/column/byte-offset Position.
+func (f *File) Position(p Pos) Position {
+	offset := int(p) - f.base
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	line, col := 1, offset+1
+	for i, start := range f.lines {
+		if start > offset {
+			break
+		}
+		line = i + 1
+		col = offset - start + 1
+	}
+	return Position{Line: line, Column: col, Position: offset, File: f}
+}
+
+// FileSet is a set of files sharing one monotonically increasing base
+// offset, analogous to go/token.FileSet. A single global Pos round-trips
+// to the (file, line, column) it came from via FileSet.Position, so
+// tokens from different files can be merged into one stream without
+// losing provenance.
+type FileSet struct {
+	mu    sync.Mutex
+	base  int
+	files []*File
+}
+
+// NewFileSet creates an empty FileSet.
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1}
+}
+
+// AddFile adds a new file of the given name and byte size to the set,
+// returning the *File used to track its line table.
+func (s *FileSet) AddFile(name string, size int) *File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := &File{name: name, base: s.base, size: size, lines: []int{0}}
+	s.files = append(s.files, f)
+	s.base += size + 1 // +1 so Pos(file.base+size) stays inside this file, not the next
+	return f
+}
+
+// File returns the *File that p belongs to, or nil if p doesn't belong to
+// any file added to the set.
+func (s *FileSet) File(p Pos) *File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range s.files {
+		if int(p) >= f.base && int(p) <= f.base+f.size {
+			return f
+		}
+	}
+	return nil
+}
+
+// Position resolves p to a line/column/byte-offset Position by finding
+// the file it belongs to. It returns the zero Position if p doesn't
+// belong to any file in the set.
+func (s *FileSet) Position(p Pos) Position {
+	f := s.File(p)
+	if f == nil {
+		return Position{}
+	}
+	return f.Position(p)
+}
@@ -14,11 +14,6 @@
 
 package token
 
-import (
-	"slices"
-	"unicode"
-)
-
 // Kind represents the token kind.
 type Kind string
 
@@ -37,6 +32,10 @@ type Position struct {
 	Line     int `json:"line"`
 	Column   int `json:"column"`
 	Position int `json:"position"`
+
+	// File is the FileSet file this Position was resolved from, or nil if
+	// the Position wasn't produced via FileSet.Position/File.Position.
+	File *File `json:"-"`
 }
 
 // Token represents the literal in the code,
@@ -54,6 +53,7 @@ const (
 	Char            Kind = "char"
 	Separator       Kind = "separator"
 	SpecialFunction Kind = "special-function"
+	Comment         Kind = "comment"
 )
 
 var (
@@ -78,28 +78,9 @@ func New(lit string, kind Kind, pos *Position) *Token {
 	return &Token{lit, pos, kind}
 }
 
-// IsIdentifier checks whether str is a valid identifier:
-//   - Must not start with digit;
-//   - Must not contain any special symbols except _;
-//   - Must not contain whitespaces;
-//   - The length must be not longer than 255 or empty;
-//   - Must not be reserved word or separator;
+// IsIdentifier checks whether str is a valid identifier under
+// DefaultVocabulary. See Vocabulary.IsIdentifier for the rules, and for
+// embedders who want a different separator/mnemonic/register set.
 func IsIdentifier(str string) bool {
-	if len(str) == 0 || len(str) > 255 {
-		return false
-	}
-
-	if slices.Contains(Separators, str) || slices.Contains(SpecialFunctions, str) {
-		return false
-	}
-
-	for idx, r := range str {
-		if idx == 0 && unicode.IsDigit(r) {
-			return false
-		}
-		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
-			return false
-		}
-	}
-	return true
+	return DefaultVocabulary.IsIdentifier(str)
 }
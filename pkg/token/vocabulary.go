@@ -0,0 +1,73 @@
+// Copyright 2026 dywoq
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"slices"
+	"unicode"
+)
+
+// Vocabulary groups the literal sets that together decide what a bare
+// word lexes as: separators, special function names, reserved
+// instruction mnemonics, register names, and directive names. Grouping
+// them behind one pluggable type, instead of the package-level
+// Separators/SpecialFunctions vars, lets an embedder swap in their own
+// instruction set (a RISC-V flavor vs. an x86 flavor, say) without
+// forking the module.
+type Vocabulary struct {
+	Separators       Slice
+	SpecialFunctions Slice
+	Mnemonics        Slice
+	Registers        Slice
+	Directives       Slice
+}
+
+// DefaultVocabulary is the Vocabulary IsIdentifier falls back to when
+// called without one, preserving this package's pre-Vocabulary behavior.
+var DefaultVocabulary = &Vocabulary{
+	Separators:       Separators,
+	SpecialFunctions: SpecialFunctions,
+}
+
+// IsIdentifier checks whether str is a valid identifier under v:
+//   - Must not start with a digit;
+//   - Must not contain any special symbols except _;
+//   - Must not contain whitespace;
+//   - The length must be non-empty and no longer than 255;
+//   - Must not be one of v's Separators, SpecialFunctions, Mnemonics,
+//     Registers or Directives.
+func (v *Vocabulary) IsIdentifier(str string) bool {
+	if len(str) == 0 || len(str) > 255 {
+		return false
+	}
+
+	if slices.Contains(v.Separators, str) ||
+		slices.Contains(v.SpecialFunctions, str) ||
+		slices.Contains(v.Mnemonics, str) ||
+		slices.Contains(v.Registers, str) ||
+		slices.Contains(v.Directives, str) {
+		return false
+	}
+
+	for idx, r := range str {
+		if idx == 0 && unicode.IsDigit(r) {
+			return false
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,10 @@
 package token
 
-import "unicode"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
 
 // Kind represents the token kind.
 type Kind string
@@ -20,6 +24,17 @@ type Position struct {
 	Line     int `json:"line"`
 	Column   int `json:"column"`
 	Position int `json:"position"`
+
+	// Filename is the source file this Position was read from, e.g. the
+	// file a %include directive pulled in, or "" if the producer never
+	// set it.
+	Filename string `json:"filename,omitempty"`
+
+	// Origin, when non-nil, is the position this one was expanded from,
+	// e.g. a %include or macro invocation's call site. It lets tooling
+	// walk a chain of expansions back to the position the user actually
+	// wrote, instead of only seeing where the expanded text came from.
+	Origin *Position `json:"origin,omitempty"`
 }
 
 // Token represents the literal in the code,
@@ -37,6 +52,9 @@ const (
 	String     Kind = "string"
 	Char       Kind = "char"
 	Separator  Kind = "separator"
+	Comment    Kind = "comment"
+	Directive  Kind = "directive"
+	Shlex      Kind = "shlex"
 )
 
 var (
@@ -69,3 +87,90 @@ func IsIdentifier(str string) bool {
 	}
 	return true
 }
+
+// New creates a Token with the given literal, kind, and position.
+func New(literal string, kind Kind, pos *Position) *Token {
+	return &Token{Literal: literal, Position: pos, Kind: kind}
+}
+
+// ShlexSplit splits s using POSIX shell quoting rules: whitespace splits
+// arguments, single quotes preserve their contents literally, double
+// quotes allow \" and \\ escapes, and an unmatched quote is an error.
+// Each resulting token is tagged Identifier if its value satisfies
+// IsIdentifier, or String otherwise. Named ShlexSplit, rather than
+// Shlex, to not collide with the Shlex Kind.
+//
+// Every synthesized Token's Position reports only the byte offset within
+// s (Position.Position, mirrored into Line/Column as if s were a single
+// line), since ShlexSplit has no notion of where s itself sits in a
+// larger file. A caller that does know (one splitting the literal of a
+// Shlex-kind token, say) should record that via Position.Origin on the
+// returned tokens.
+func ShlexSplit(s string) ([]*Token, error) {
+	var tokens []*Token
+	var cur strings.Builder
+	started := false
+	startOffset := 0
+
+	flush := func() {
+		if !started {
+			return
+		}
+		lit := cur.String()
+		kind := String
+		if IsIdentifier(lit) {
+			kind = Identifier
+		}
+		tokens = append(tokens, New(lit, kind, &Position{Line: 1, Column: startOffset + 1, Position: startOffset}))
+		cur.Reset()
+		started = false
+	}
+
+	i := 0
+	for i < len(s) {
+		switch c := s[i]; {
+		case unicode.IsSpace(rune(c)):
+			flush()
+			i++
+		case c == '\'':
+			if !started {
+				started, startOffset = true, i
+			}
+			j := i + 1
+			for j < len(s) && s[j] != '\'' {
+				cur.WriteByte(s[j])
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("token: ShlexSplit: unmatched ' starting at offset %v", i)
+			}
+			i = j + 1
+		case c == '"':
+			if !started {
+				started, startOffset = true, i
+			}
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' && j+1 < len(s) && (s[j+1] == '"' || s[j+1] == '\\') {
+					cur.WriteByte(s[j+1])
+					j += 2
+					continue
+				}
+				cur.WriteByte(s[j])
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("token: ShlexSplit: unmatched \" starting at offset %v", i)
+			}
+			i = j + 1
+		default:
+			if !started {
+				started, startOffset = true, i
+			}
+			cur.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return tokens, nil
+}